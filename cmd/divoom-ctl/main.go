@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"divoom-monitor/internal/divoom"
+)
+
+var version = "dev" // Set by build flags
+
+const defaultAPIAddr = "127.0.0.1:9102"
+
+type CtlClient struct {
+	http    *http.Client
+	addr    string
+	baseURL string
+}
+
+func main() {
+	var showVersion = flag.Bool("version", false, "Show version information")
+	var addr = flag.String("addr", envOr("DIVOOM_API_ADDR", defaultAPIAddr), "Daemon control API address (host:port or unix:/path)")
+	flag.Usage = usage
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("divoom-ctl version %s\n", version)
+		return
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	client := newCtlClient(*addr)
+	if err := run(client, args[0], args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Println("divoom-ctl - Control a running divoom-daemon")
+	fmt.Printf("Version: %s\n\n", version)
+	fmt.Println("Usage:")
+	fmt.Println("  divoom-ctl [flags] <command> [args]")
+	fmt.Println("\nCommands:")
+	fmt.Println("  status              Show current device, LCD and last send result")
+	fmt.Println("  devices             List devices found on the network")
+	fmt.Println("  use <ip> [lcd]      Send updates to another device")
+	fmt.Println("  lcd <id>            Send updates to another LCD (0-4)")
+	fmt.Println("  pause               Stop sending updates")
+	fmt.Println("  resume              Resume sending updates")
+	fmt.Println("  text <message>      Show a one-off text message")
+	fmt.Println("  rediscover          Rerun device discovery")
+	fmt.Println("  conf                Show the device configuration")
+	fmt.Println("  brightness <0-100>  Set the display brightness")
+	fmt.Println("  screen on|off       Switch the display on or off")
+	fmt.Println("  channel <channel>   Select a channel (0-4, faces, cloud, visualizer, custom, black)")
+	fmt.Println("  clock <id>          Select a clock face (pcmonitor for 625)")
+	fmt.Println("  pages               List the carousel pages")
+	fmt.Println("  page <name>         Pin a page (next: show the next page, auto: resume rotation)")
+	fmt.Println("\nFlags:")
+	flag.PrintDefaults()
+	fmt.Println("\nThe daemon only serves the control API when started with --api-addr, e.g.")
+	fmt.Printf("  divoom-daemon --api-addr=%s\n", defaultAPIAddr)
+}
+
+func run(client *CtlClient, command string, args []string) error {
+	switch command {
+	case "status":
+		return client.call(http.MethodGet, "/status", nil)
+	case "devices":
+		return client.call(http.MethodGet, "/devices", nil)
+	case "use":
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("usage: divoom-ctl use <ip> [lcd]")
+		}
+		req := map[string]interface{}{"DevicePrivateIP": args[0]}
+		if len(args) == 2 {
+			lcd, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid LCD ID: %s", args[1])
+			}
+			req["LcdId"] = lcd
+		}
+		return client.call(http.MethodPost, "/device", req)
+	case "lcd":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: divoom-ctl lcd <id>")
+		}
+		lcd, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid LCD ID: %s", args[0])
+		}
+		return client.call(http.MethodPost, "/lcd", map[string]int{"LcdId": lcd})
+	case "pause":
+		return client.call(http.MethodPost, "/pause", nil)
+	case "resume":
+		return client.call(http.MethodPost, "/resume", nil)
+	case "text":
+		fs := flag.NewFlagSet("text", flag.ExitOnError)
+		color := fs.String("color", "", "Text color (e.g. #FF0000)")
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			return fmt.Errorf("usage: divoom-ctl text [--color #RRGGBB] <message>")
+		}
+		return client.call(http.MethodPost, "/text", map[string]string{
+			"Text":  strings.Join(fs.Args(), " "),
+			"Color": *color,
+		})
+	case "rediscover":
+		return client.call(http.MethodPost, "/rediscover", nil)
+	case "conf":
+		return client.call(http.MethodGet, "/conf", nil)
+	case "brightness":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: divoom-ctl brightness <0-100>")
+		}
+		brightness, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid brightness: %s", args[0])
+		}
+		return client.call(http.MethodPost, "/brightness", map[string]int{"Brightness": brightness})
+	case "screen":
+		if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+			return fmt.Errorf("usage: divoom-ctl screen on|off")
+		}
+		return client.call(http.MethodPost, "/screen", map[string]bool{"On": args[0] == "on"})
+	case "channel":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: divoom-ctl channel <channel>")
+		}
+		index, err := divoom.ParseChannel(args[0])
+		if err != nil {
+			return err
+		}
+		return client.call(http.MethodPost, "/channel", map[string]int{"SelectIndex": index})
+	case "clock":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: divoom-ctl clock <id>")
+		}
+		clockId := divoom.PCMonitorClockId
+		if args[0] != "pcmonitor" {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid clock ID: %s", args[0])
+			}
+			clockId = id
+		}
+		return client.call(http.MethodPost, "/clock", map[string]int{"ClockId": clockId})
+	case "pages":
+		return client.call(http.MethodGet, "/pages", nil)
+	case "page":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: divoom-ctl page <name>|next|auto")
+		}
+		switch args[0] {
+		case "next":
+			return client.call(http.MethodPost, "/page/next", nil)
+		case "auto":
+			return client.call(http.MethodPost, "/page", map[string]string{"Name": ""})
+		default:
+			return client.call(http.MethodPost, "/page", map[string]string{"Name": args[0]})
+		}
+	default:
+		return fmt.Errorf("unknown command %q (see divoom-ctl --help)", command)
+	}
+}
+
+func newCtlClient(addr string) *CtlClient {
+	client := &CtlClient{
+		http:    &http.Client{Timeout: 15 * time.Second},
+		addr:    addr,
+		baseURL: "http://" + addr,
+	}
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		client.baseURL = "http://divoom-daemon"
+		client.http.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}
+	}
+	return client
+}
+
+// call performs one API request and prints the JSON response.
+func (c *CtlClient) call(method, path string, body interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	// The daemon takes POSTs only as JSON, even without a body
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// The daemon serves no API unless it was started with --api-addr
+		return fmt.Errorf("cannot reach the daemon at %s: %v\n"+
+			"Start divoom-daemon with --api-addr=%s, or point --addr (or DIVOOM_API_ADDR) at the address it serves",
+			c.addr, err, c.addr)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct{ Error string }
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("daemon returned status %d: %s", resp.StatusCode, apiErr.Error)
+		}
+		return fmt.Errorf("daemon returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, respBody, "", "  "); err != nil {
+		os.Stdout.Write(respBody)
+		return nil
+	}
+	fmt.Println(strings.TrimSpace(out.String()))
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
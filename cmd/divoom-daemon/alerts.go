@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"divoom-monitor/internal/divoom"
+)
+
+// AlertRule switches the display to an alert view while a metric is past a
+// threshold. The view is either a text banner (Text/Color), a different
+// clock face (ClockId) or a carousel page (Page).
+type AlertRule struct {
+	Name       string   `json:"Name"`
+	Metric     string   `json:"Metric"`
+	Op         string   `json:"Op"`
+	Threshold  float64  `json:"Threshold"`
+	For        Duration `json:"For"`
+	Hysteresis float64  `json:"Hysteresis"`
+	Text       string   `json:"Text"`
+	Color      string   `json:"Color"`
+	ClockId    int      `json:"ClockId"`
+	Page       string   `json:"Page"`
+}
+
+func (r *AlertRule) validate() error {
+	if _, ok := (DaemonHardwareData{}).Metric(r.Metric); !ok {
+		return fmt.Errorf("unknown metric %q", r.Metric)
+	}
+	switch r.Op {
+	case ">", ">=", "<", "<=":
+	case "":
+		r.Op = ">"
+	default:
+		return fmt.Errorf("unknown comparison %q", r.Op)
+	}
+	if r.Hysteresis < 0 {
+		return fmt.Errorf("hysteresis must not be negative")
+	}
+	if r.Name == "" {
+		r.Name = fmt.Sprintf("%s %s %s", r.Metric, r.Op, formatAlertValue(r.Threshold))
+	}
+	if r.Text == "" && r.ClockId == 0 && r.Page == "" {
+		r.Text = "{Name}: {Value}"
+	}
+	if r.Color == "" {
+		r.Color = "#FF0000"
+	}
+	return nil
+}
+
+// breached reports whether value is past threshold in the rule's direction.
+func (r *AlertRule) breached(value, threshold float64) bool {
+	switch r.Op {
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	default:
+		return value > threshold
+	}
+}
+
+// clearThreshold moves the threshold back by the hysteresis so a value
+// hovering around it does not make the alert flap.
+func (r *AlertRule) clearThreshold() float64 {
+	if r.Op == "<" || r.Op == "<=" {
+		return r.Threshold + r.Hysteresis
+	}
+	return r.Threshold - r.Hysteresis
+}
+
+// bannerText expands {Name}, {Metric}, {Value} and {Threshold} in Text.
+func (r *AlertRule) bannerText(value float64) string {
+	return strings.NewReplacer(
+		"{Name}", r.Name,
+		"{Metric}", r.Metric,
+		"{Value}", formatAlertValue(value),
+		"{Threshold}", formatAlertValue(r.Threshold),
+	).Replace(r.Text)
+}
+
+func formatAlertValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+type alertState struct {
+	rule         AlertRule
+	pendingSince time.Time
+	firing       bool
+	value        float64
+}
+
+// AlertTransition is emitted when a rule starts or stops firing.
+type AlertTransition struct {
+	Rule   AlertRule
+	Firing bool
+	Value  float64
+}
+
+func (t AlertTransition) String() string {
+	if t.Firing {
+		return fmt.Sprintf("Alert %q firing: %s=%s (%s %s)", t.Rule.Name, t.Rule.Metric,
+			formatAlertValue(t.Value), t.Rule.Op, formatAlertValue(t.Rule.Threshold))
+	}
+	return fmt.Sprintf("Alert %q cleared: %s=%s", t.Rule.Name, t.Rule.Metric, formatAlertValue(t.Value))
+}
+
+// AlertManager evaluates the configured rules against each sample.
+type AlertManager struct {
+	alerts []*alertState
+}
+
+func newAlertManager(rules []AlertRule) *AlertManager {
+	m := &AlertManager{}
+	for _, rule := range rules {
+		m.alerts = append(m.alerts, &alertState{rule: rule})
+	}
+	return m
+}
+
+// Evaluate updates every rule with data and returns the rules that changed
+// state.
+func (m *AlertManager) Evaluate(data DaemonHardwareData, now time.Time) []AlertTransition {
+	var transitions []AlertTransition
+	for _, a := range m.alerts {
+		value, _ := data.Metric(a.rule.Metric)
+		a.value = value
+
+		if a.firing {
+			if !a.rule.breached(value, a.rule.clearThreshold()) {
+				a.firing = false
+				a.pendingSince = time.Time{}
+				transitions = append(transitions, AlertTransition{Rule: a.rule, Firing: false, Value: value})
+			}
+			continue
+		}
+
+		if !a.rule.breached(value, a.rule.Threshold) {
+			a.pendingSince = time.Time{}
+			continue
+		}
+		if a.pendingSince.IsZero() {
+			a.pendingSince = now
+		}
+		if now.Sub(a.pendingSince) >= a.rule.For.Duration {
+			a.firing = true
+			transitions = append(transitions, AlertTransition{Rule: a.rule, Firing: true, Value: value})
+		}
+	}
+	return transitions
+}
+
+// Active returns the first firing rule in configuration order, or nil.
+func (m *AlertManager) Active() *alertState {
+	for _, a := range m.alerts {
+		if a.firing {
+			return a
+		}
+	}
+	return nil
+}
+
+// AlertDisplay tracks which alert view is on the device so it can be
+// reverted once the alert clears.
+type AlertDisplay struct {
+	shown *AlertRule
+}
+
+// Update puts the view for active on the device, or restores the normal
+// display when active is nil. It reports whether the alert view replaced
+// the regular update for this tick. The view is only remembered once the
+// device has accepted it, and forgotten once the revert has succeeded, so
+// failures are retried on the next tick.
+func (d *AlertDisplay) Update(device DaemonDevice, active *alertState) (bool, error) {
+	if d.shown != nil && (active == nil || active.rule.Name != d.shown.Name) {
+		if err := revertAlertView(device, *d.shown); err != nil {
+			return active != nil, err
+		}
+		d.shown = nil
+	}
+	if active == nil {
+		return false, nil
+	}
+
+	rule := active.rule
+	first := d.shown == nil
+
+	if rule.ClockId != 0 {
+		if !first {
+			return true, nil
+		}
+		if err := postDaemonCommand(device, DaemonClockPayload{
+			Command: "Channel/SetClockSelectId",
+			ClockId: rule.ClockId,
+		}); err != nil {
+			return true, err
+		}
+		d.shown = &rule
+		return true, nil
+	}
+	if first {
+		// Clear text mode lines so the banner is shown alone
+		if err := postDaemonCommand(device, DaemonCommandPayload{Command: "Draw/ClearHttpText"}); err != nil {
+			return true, err
+		}
+	}
+	// Resend the banner every tick so it shows the current value
+	if err := sendDaemonText(device, rule.bannerText(active.value), rule.Color); err != nil {
+		return true, err
+	}
+	d.shown = &rule
+	return true, nil
+}
+
+func revertAlertView(device DaemonDevice, rule AlertRule) error {
+	if rule.ClockId != 0 {
+		return postDaemonCommand(device, DaemonClockPayload{
+			Command: "Channel/SetClockSelectId",
+			ClockId: divoom.PCMonitorClockId,
+		})
+	}
+	return postDaemonCommand(device, DaemonCommandPayload{Command: "Draw/ClearHttpText"})
+}
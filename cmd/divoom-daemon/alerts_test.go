@@ -0,0 +1,167 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestAlerts(t *testing.T, rules ...AlertRule) *AlertManager {
+	t.Helper()
+	for i := range rules {
+		if err := rules[i].validate(); err != nil {
+			t.Fatalf("validate %+v: %v", rules[i], err)
+		}
+	}
+	return newAlertManager(rules)
+}
+
+func TestAlertRuleDefaults(t *testing.T) {
+	rule := AlertRule{Metric: "CpuTemp", Threshold: 85}
+	if err := rule.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if rule.Op != ">" || rule.Name != "CpuTemp > 85" || rule.Text != "{Name}: {Value}" || rule.Color != "#FF0000" {
+		t.Errorf("defaults = %+v", rule)
+	}
+	if got := rule.bannerText(91.5); got != "CpuTemp > 85: 91.5" {
+		t.Errorf("bannerText = %q", got)
+	}
+
+	for _, bad := range []AlertRule{
+		{Metric: "FanSpeed"},
+		{Metric: "CpuTemp", Op: "=="},
+		{Metric: "CpuTemp", Hysteresis: -1},
+	} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("validate accepted %+v", bad)
+		}
+	}
+}
+
+func TestAlertForAndHysteresis(t *testing.T) {
+	m := newTestAlerts(t, AlertRule{Name: "hot", Metric: "CpuTemp", Threshold: 80, For: Duration{30 * time.Second}, Hysteresis: 5})
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		after  time.Duration
+		temp   int
+		change string // "", "firing" or "cleared"
+	}{
+		{0, 85, ""},                // pending
+		{10 * time.Second, 70, ""}, // dropped below, pending reset
+		{20 * time.Second, 85, ""}, // pending again
+		{49 * time.Second, 90, ""}, // 29s over
+		{50 * time.Second, 90, "firing"},
+		{60 * time.Second, 78, ""},        // below the threshold, within the hysteresis
+		{70 * time.Second, 75, "cleared"}, // not above the clear threshold of 75
+		{80 * time.Second, 85, ""},        // pending again, not firing at once
+		{110 * time.Second, 85, "firing"},
+	}
+	for _, step := range steps {
+		transitions := m.Evaluate(DaemonHardwareData{CpuTemp: step.temp}, start.Add(step.after))
+		change := ""
+		if len(transitions) == 1 {
+			change = "cleared"
+			if transitions[0].Firing {
+				change = "firing"
+			}
+		} else if len(transitions) > 1 {
+			t.Fatalf("at %s: %d transitions", step.after, len(transitions))
+		}
+		if change != step.change {
+			t.Errorf("at %s, %d°C: got %q, want %q", step.after, step.temp, change, step.change)
+		}
+	}
+}
+
+func TestAlertBelowThreshold(t *testing.T) {
+	m := newTestAlerts(t, AlertRule{Name: "idle", Metric: "CpuUsage", Op: "<", Threshold: 5, Hysteresis: 2})
+	now := time.Now()
+	if ts := m.Evaluate(DaemonHardwareData{CpuUsage: 3}, now); len(ts) != 1 || !ts[0].Firing {
+		t.Fatalf("3%% < 5%%: %v", ts)
+	}
+	if ts := m.Evaluate(DaemonHardwareData{CpuUsage: 6}, now); len(ts) != 0 {
+		t.Errorf("6%% is within the hysteresis but cleared: %v", ts)
+	}
+	if ts := m.Evaluate(DaemonHardwareData{CpuUsage: 8}, now); len(ts) != 1 || ts[0].Firing {
+		t.Errorf("8%%: %v, want cleared", ts)
+	}
+	if m.Active() != nil {
+		t.Errorf("alert still active")
+	}
+}
+
+func deviceShowsText(texts map[int]string, text string) bool {
+	for _, shown := range texts {
+		if shown == text {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAlertDisplayBanner(t *testing.T) {
+	s, device := newTestDevice(t)
+	m := newTestAlerts(t, AlertRule{Name: "hot", Metric: "CpuTemp", Threshold: 80, Text: "HOT {Value}"})
+	display := &AlertDisplay{}
+
+	m.Evaluate(DaemonHardwareData{CpuTemp: 90}, time.Now())
+	if alerting, err := display.Update(device, m.Active()); !alerting || err != nil {
+		t.Fatalf("Update = %v, %v", alerting, err)
+	}
+	if !deviceShowsText(s.State().Text, "HOT 90") {
+		t.Errorf("device shows %v", s.State().Text)
+	}
+
+	// The banner follows the value
+	m.Evaluate(DaemonHardwareData{CpuTemp: 92}, time.Now())
+	display.Update(device, m.Active())
+	if !deviceShowsText(s.State().Text, "HOT 92") {
+		t.Errorf("device shows %v", s.State().Text)
+	}
+
+	m.Evaluate(DaemonHardwareData{CpuTemp: 50}, time.Now())
+	if alerting, err := display.Update(device, m.Active()); alerting || err != nil {
+		t.Fatalf("Update after clear = %v, %v", alerting, err)
+	}
+	if texts := s.State().Text; len(texts) != 0 {
+		t.Errorf("banner not cleared: %v", texts)
+	}
+}
+
+func TestAlertDisplayRetriesFailures(t *testing.T) {
+	s, device := newTestDevice(t)
+	m := newTestAlerts(t, AlertRule{Name: "hot", Metric: "CpuTemp", Threshold: 80, ClockId: 12})
+	display := &AlertDisplay{}
+	m.Evaluate(DaemonHardwareData{CpuTemp: 90}, time.Now())
+
+	// A failed switch is tried again on the next tick
+	s.SetErrorCode(1)
+	if _, err := display.Update(device, m.Active()); err == nil {
+		t.Fatalf("switch succeeded despite error_code 1")
+	}
+	s.SetErrorCode(0)
+	if _, err := display.Update(device, m.Active()); err != nil {
+		t.Fatal(err)
+	}
+	if clock := s.State().CurClockId; clock != 12 {
+		t.Fatalf("clock %d after retry, want 12", clock)
+	}
+
+	// So is a failed revert
+	m.Evaluate(DaemonHardwareData{CpuTemp: 50}, time.Now())
+	s.SetErrorCode(1)
+	if _, err := display.Update(device, m.Active()); err == nil {
+		t.Fatalf("revert succeeded despite error_code 1")
+	}
+	s.SetErrorCode(0)
+	if _, err := display.Update(device, m.Active()); err != nil {
+		t.Fatal(err)
+	}
+	if clock := s.State().CurClockId; clock != 625 {
+		t.Errorf("clock %d after the alert cleared, want 625", clock)
+	}
+	if got := len(s.CommandsNamed("Channel/SetClockSelectId")); got != 4 {
+		t.Errorf("%d SetClockSelectId commands, want 4", got)
+	}
+}
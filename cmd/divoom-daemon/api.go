@@ -0,0 +1,445 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"divoom-monitor/internal/divoom"
+)
+
+// The control API lets divoom-ctl (or curl) inspect and steer a running
+// daemon. It is only served on a unix socket or a loopback address, POST
+// bodies must be sent as application/json and requests from web pages are
+// refused (see checkAPIRequest).
+//
+//	GET  /status      current target, pause state and last send result
+//	GET  /devices     run discovery and list devices on the LAN
+//	POST /device      {"DevicePrivateIP": "...", "LcdId": 0} switch target
+//	POST /lcd         {"LcdId": 1} switch LCD on the current device
+//	POST /pause       stop sending updates
+//	POST /resume      resume sending updates
+//	POST /text        {"Text": "...", "Color": "#FF0000"} show a message
+//	POST /rediscover  rerun discovery and refresh the target
+//	GET  /conf        read the device configuration (Channel/GetAllConf)
+//	POST /brightness  {"Brightness": 50} set brightness (0-100)
+//	POST /screen      {"On": false} switch the display on or off
+//	POST /channel     {"SelectIndex": 0} select a channel (0-4)
+//	POST /clock       {"ClockId": 625} select a clock face
+//	GET  /pages       list the carousel pages
+//	POST /page        {"Name": "temps"} pin a page, "" resumes the rotation
+//	POST /page/next   show the next page
+
+type apiDeviceRequest struct {
+	DevicePrivateIP string `json:"DevicePrivateIP"`
+	DeviceId        int    `json:"DeviceId"`
+	LcdId           *int   `json:"LcdId"`
+}
+
+type apiLcdRequest struct {
+	LcdId int `json:"LcdId"`
+}
+
+type apiTextRequest struct {
+	Text  string `json:"Text"`
+	Color string `json:"Color"`
+}
+
+type apiBrightnessRequest struct {
+	Brightness int `json:"Brightness"`
+}
+
+type apiScreenRequest struct {
+	On bool `json:"On"`
+}
+
+type apiChannelRequest struct {
+	SelectIndex int `json:"SelectIndex"`
+}
+
+type apiClockRequest struct {
+	ClockId int `json:"ClockId"`
+}
+
+type apiPageRequest struct {
+	Name string `json:"Name"`
+}
+
+type apiError struct {
+	Error string `json:"Error"`
+}
+
+func startAPIServer(addr string) error {
+	listener, err := listenAPI(addr)
+	if err != nil {
+		return err
+	}
+
+	handler := newAPIHandler()
+	go func() {
+		logger.Info("Serving control API", "addr", addr)
+		if err := http.Serve(listener, handler); err != nil {
+			logger.Error("Control API stopped", "err", err)
+		}
+	}()
+	return nil
+}
+
+func newAPIHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", apiMethod(http.MethodGet, handleAPIStatus))
+	mux.HandleFunc("/devices", apiMethod(http.MethodGet, handleAPIDevices))
+	mux.HandleFunc("/device", apiMethod(http.MethodPost, handleAPIDevice))
+	mux.HandleFunc("/lcd", apiMethod(http.MethodPost, handleAPILcd))
+	mux.HandleFunc("/pause", apiMethod(http.MethodPost, handleAPIPause))
+	mux.HandleFunc("/resume", apiMethod(http.MethodPost, handleAPIResume))
+	mux.HandleFunc("/text", apiMethod(http.MethodPost, handleAPIText))
+	mux.HandleFunc("/rediscover", apiMethod(http.MethodPost, handleAPIRediscover))
+	mux.HandleFunc("/conf", apiMethod(http.MethodGet, handleAPIConf))
+	mux.HandleFunc("/brightness", apiMethod(http.MethodPost, handleAPIBrightness))
+	mux.HandleFunc("/screen", apiMethod(http.MethodPost, handleAPIScreen))
+	mux.HandleFunc("/channel", apiMethod(http.MethodPost, handleAPIChannel))
+	mux.HandleFunc("/clock", apiMethod(http.MethodPost, handleAPIClock))
+	mux.HandleFunc("/pages", apiMethod(http.MethodGet, handleAPIPages))
+	mux.HandleFunc("/page", apiMethod(http.MethodPost, handleAPIPage))
+	mux.HandleFunc("/page/next", apiMethod(http.MethodPost, handleAPIPageNext))
+	return mux
+}
+
+// listenAPI accepts "unix:/path/to.sock" or a host:port that must resolve to
+// a loopback address.
+func listenAPI(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		// Remove a stale socket left behind by a previous run, but never
+		// anything else that happens to be at the path
+		if info, err := os.Lstat(path); err == nil {
+			if info.Mode()&os.ModeSocket == 0 {
+				return nil, fmt.Errorf("%s exists and is not a socket", path)
+			}
+			if err := os.Remove(path); err != nil {
+				return nil, err
+			}
+		}
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(path, 0660); err != nil {
+			listener.Close()
+			return nil, err
+		}
+		return listener, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if host != "localhost" {
+		ip := net.ParseIP(host)
+		if ip == nil || !ip.IsLoopback() {
+			return nil, fmt.Errorf("control API must listen on a loopback address, got %q", host)
+		}
+	}
+	return net.Listen("tcp", addr)
+}
+
+func apiMethod(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		if err := checkAPIRequest(r); err != nil {
+			writeAPIError(w, http.StatusForbidden, err)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// checkAPIRequest keeps web pages away from the API. Browsers send an Origin
+// header with cross-site requests, can post text/plain forms without a CORS
+// preflight and, after DNS rebinding, send their own host name as Host. None
+// of this happens with divoom-ctl or curl.
+func checkAPIRequest(r *http.Request) error {
+	if r.Header.Get("Origin") != "" {
+		return fmt.Errorf("cross-origin requests are not allowed")
+	}
+	if r.Method == http.MethodPost {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			return fmt.Errorf("Content-Type must be application/json")
+		}
+	}
+	// A unix socket cannot be reached from a browser, and clients put any
+	// name in Host
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok && addr.Network() == "unix" {
+		return nil
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host != "localhost" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return fmt.Errorf("host %q is not a loopback address", r.Host)
+		}
+	}
+	return nil
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, apiError{Error: err.Error()})
+}
+
+func decodeAPIRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func handleAPIStatus(w http.ResponseWriter, r *http.Request) {
+	writeAPIJSON(w, http.StatusOK, daemonState.Status())
+}
+
+func handleAPIDevices(w http.ResponseWriter, r *http.Request) {
+	devices, err := findDaemonDevices()
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, DaemonDeviceList{TotalData: len(devices), DeviceList: devices})
+}
+
+func handleAPIDevice(w http.ResponseWriter, r *http.Request) {
+	var req apiDeviceRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+
+	_, lcdId := daemonState.Target()
+	if req.LcdId != nil {
+		lcdId = *req.LcdId
+	}
+
+	device := DaemonDevice{DevicePrivateIP: req.DevicePrivateIP, DeviceId: req.DeviceId}
+	if req.DeviceId != 0 {
+		// Look the device up so the status shows its name and address
+		devices, err := findDaemonDevices()
+		if err != nil {
+			writeAPIError(w, http.StatusBadGateway, err)
+			return
+		}
+		found := false
+		for _, d := range devices {
+			if d.DeviceId == req.DeviceId {
+				device, found = d, true
+				break
+			}
+		}
+		if !found {
+			writeAPIError(w, http.StatusNotFound, fmt.Errorf("device %d not found", req.DeviceId))
+			return
+		}
+	} else if !validDeviceAddr(req.DevicePrivateIP) {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid device IP %q", req.DevicePrivateIP))
+		return
+	}
+
+	if err := checkLcd(device, lcdId); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	daemonState.SetTarget(device, lcdId)
+	logger.Info("API: switched device", "device", device.DevicePrivateIP, "lcd", lcdId)
+	writeAPIJSON(w, http.StatusOK, daemonState.Status())
+}
+
+// validDeviceAddr accepts an IP address, optionally with a port.
+func validDeviceAddr(addr string) bool {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(addr) != nil
+}
+
+func handleAPILcd(w http.ResponseWriter, r *http.Request) {
+	var req apiLcdRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	device, _ := daemonState.Target()
+	if err := checkLcd(device, req.LcdId); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	daemonState.SetLcd(req.LcdId)
+	logger.Info("API: switched LCD", "lcd", req.LcdId)
+	writeAPIJSON(w, http.StatusOK, daemonState.Status())
+}
+
+func handleAPIPause(w http.ResponseWriter, r *http.Request) {
+	daemonState.SetPaused(true)
+	logger.Info("API: updates paused")
+	writeAPIJSON(w, http.StatusOK, daemonState.Status())
+}
+
+func handleAPIResume(w http.ResponseWriter, r *http.Request) {
+	daemonState.SetPaused(false)
+	logger.Info("API: updates resumed")
+	writeAPIJSON(w, http.StatusOK, daemonState.Status())
+}
+
+func handleAPIText(w http.ResponseWriter, r *http.Request) {
+	var req apiTextRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	if req.Text == "" {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("text must not be empty"))
+		return
+	}
+
+	device, _ := daemonState.Target()
+	if err := sendDaemonText(device, req.Text, req.Color); err != nil {
+		writeAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	logger.Info("API: sent text", "text", req.Text)
+	writeAPIJSON(w, http.StatusOK, daemonState.Status())
+}
+
+func handleAPIRediscover(w http.ResponseWriter, r *http.Request) {
+	devices, err := daemonState.Rediscover()
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, DaemonDeviceList{TotalData: len(devices), DeviceList: devices})
+}
+
+// deviceClient returns a client for the typed device commands, sharing the
+// daemon's endpoints and HTTP client.
+func deviceClient() *divoom.Client {
+	return &divoom.Client{Endpoints: daemonEndpoints, HTTP: daemonHttpClient}
+}
+
+func handleAPIConf(w http.ResponseWriter, r *http.Request) {
+	device, _ := daemonState.Target()
+	conf, err := deviceClient().GetAllConf(device.DevicePrivateIP)
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, conf)
+}
+
+// finishDeviceCommand reports the result of a device command. The screen
+// content may have changed, so the next update is sent in full.
+func finishDeviceCommand(w http.ResponseWriter, device DaemonDevice, err error, action string) {
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	daemonPayloads.ForgetDevice(device.DevicePrivateIP)
+	logger.Info("API: "+action, "device", device.DevicePrivateIP)
+	writeAPIJSON(w, http.StatusOK, daemonState.Status())
+}
+
+func handleAPIBrightness(w http.ResponseWriter, r *http.Request) {
+	var req apiBrightnessRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	if req.Brightness < 0 || req.Brightness > 100 {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("brightness must be between 0 and 100"))
+		return
+	}
+	device, _ := daemonState.Target()
+	err := deviceClient().SetBrightness(device.DevicePrivateIP, req.Brightness)
+	finishDeviceCommand(w, device, err, fmt.Sprintf("set brightness to %d", req.Brightness))
+}
+
+func handleAPIScreen(w http.ResponseWriter, r *http.Request) {
+	var req apiScreenRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	state := "off"
+	if req.On {
+		state = "on"
+	}
+	device, _ := daemonState.Target()
+	err := deviceClient().SetScreen(device.DevicePrivateIP, req.On)
+	finishDeviceCommand(w, device, err, "switched screen "+state)
+}
+
+func handleAPIChannel(w http.ResponseWriter, r *http.Request) {
+	var req apiChannelRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	if req.SelectIndex < divoom.ChannelFaces || req.SelectIndex > divoom.ChannelBlack {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("channel must be between 0 and 4"))
+		return
+	}
+	device, _ := daemonState.Target()
+	err := deviceClient().SetChannel(device.DevicePrivateIP, req.SelectIndex)
+	finishDeviceCommand(w, device, err, fmt.Sprintf("selected channel %d", req.SelectIndex))
+}
+
+func handleAPIClock(w http.ResponseWriter, r *http.Request) {
+	var req apiClockRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	if req.ClockId <= 0 {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid clock ID %d", req.ClockId))
+		return
+	}
+	device, _ := daemonState.Target()
+	err := deviceClient().SetClock(device.DevicePrivateIP, req.ClockId)
+	finishDeviceCommand(w, device, err, fmt.Sprintf("selected clock %d", req.ClockId))
+}
+
+func handleAPIPages(w http.ResponseWriter, r *http.Request) {
+	writeAPIJSON(w, http.StatusOK, daemonPages.Status())
+}
+
+func handleAPIPage(w http.ResponseWriter, r *http.Request) {
+	var req apiPageRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	if err := daemonPages.Pin(req.Name); err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+	if req.Name == "" {
+		logger.Info("API: resumed page rotation")
+	} else {
+		logger.Info("API: pinned page", "page", req.Name)
+	}
+	writeAPIJSON(w, http.StatusOK, daemonPages.Status())
+}
+
+func handleAPIPageNext(w http.ResponseWriter, r *http.Request) {
+	logger.Info("API: showing next page", "page", daemonPages.Next())
+	writeAPIJSON(w, http.StatusOK, daemonPages.Status())
+}
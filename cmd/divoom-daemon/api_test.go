@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListenAPIRequiresLoopback(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:0", "localhost:0"} {
+		listener, err := listenAPI(addr)
+		if err != nil {
+			t.Errorf("%s: %v", addr, err)
+			continue
+		}
+		listener.Close()
+	}
+	for _, addr := range []string{"0.0.0.0:0", ":0", "192.168.1.2:0", "example.com:0", "127.0.0.1"} {
+		if listener, err := listenAPI(addr); err == nil {
+			listener.Close()
+			t.Errorf("listening on %s", addr)
+		}
+	}
+}
+
+func TestListenAPIReplacesOnlySockets(t *testing.T) {
+	// Unix socket paths are short, so stay out of the long t.TempDir()
+	dir, err := os.MkdirTemp("", "divoom-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, []byte("keep"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if listener, err := listenAPI("unix:" + file); err == nil {
+		listener.Close()
+		t.Errorf("listening on a regular file")
+	}
+	if data, err := os.ReadFile(file); err != nil || string(data) != "keep" {
+		t.Errorf("regular file changed: %q, %v", data, err)
+	}
+
+	// A socket left behind by a previous run is replaced
+	socket := filepath.Join(dir, "api.sock")
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: socket, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+	listener, err := listenAPI("unix:" + socket)
+	if err != nil {
+		t.Fatalf("stale socket: %v", err)
+	}
+	listener.Close()
+}
+
+// newTestAPI serves the control API with fresh daemon state and pages.
+func newTestAPI(t *testing.T) *httptest.Server {
+	t.Helper()
+	state, pages := daemonState, daemonPages
+	daemonState, daemonPages = &DaemonState{}, &Carousel{pinned: -1, alert: -1}
+	t.Cleanup(func() { daemonState, daemonPages = state, pages })
+
+	server := httptest.NewServer(newAPIHandler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+func postAPI(t *testing.T, server *httptest.Server, path, body string, v interface{}) int {
+	t.Helper()
+	resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if v != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAPIPauseResume(t *testing.T) {
+	server := newTestAPI(t)
+
+	var status DaemonStatus
+	if code := postAPI(t, server, "/pause", "", &status); code != http.StatusOK || !status.Paused {
+		t.Errorf("/pause: %d, paused %v", code, status.Paused)
+	}
+	if !daemonState.Paused() {
+		t.Errorf("daemon not paused")
+	}
+	if code := postAPI(t, server, "/resume", "", &status); code != http.StatusOK || status.Paused {
+		t.Errorf("/resume: %d, paused %v", code, status.Paused)
+	}
+
+	resp, err := http.Get(server.URL + "/pause")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /pause: %d", resp.StatusCode)
+	}
+}
+
+func TestAPIText(t *testing.T) {
+	s, device := newTestDevice(t)
+	server := newTestAPI(t)
+	daemonState.SetTarget(device, 0)
+
+	if code := postAPI(t, server, "/text", `{"Text": "Build passed", "Color": "#00FF00"}`, nil); code != http.StatusOK {
+		t.Fatalf("/text: %d", code)
+	}
+	if text := s.State().Text[messageTextId]; text != "Build passed" {
+		t.Errorf("device shows %q", text)
+	}
+	if code := postAPI(t, server, "/text", `{"Text": ""}`, nil); code != http.StatusBadRequest {
+		t.Errorf("empty text: %d", code)
+	}
+	if code := postAPI(t, server, "/text", `{"Text":`, nil); code != http.StatusBadRequest {
+		t.Errorf("invalid body: %d", code)
+	}
+	s.SetErrorCode(1)
+	if code := postAPI(t, server, "/text", `{"Text": "Build failed"}`, nil); code != http.StatusBadGateway {
+		t.Errorf("rejected by the device: %d", code)
+	}
+}
+
+func TestAPIPages(t *testing.T) {
+	server := newTestAPI(t)
+	pages := []PageConfig{
+		{Name: "usage", Duration: Duration{time.Hour}},
+		{Name: "temps", Duration: Duration{time.Hour}},
+	}
+	if err := validatePages(pages); err != nil {
+		t.Fatal(err)
+	}
+	daemonPages.Configure(pages)
+	daemonPages.Select(time.Now(), "")
+
+	var status CarouselStatus
+	if code := postAPI(t, server, "/page/next", "", &status); code != http.StatusOK || status.Current != "temps" {
+		t.Errorf("/page/next: %d, current %q", code, status.Current)
+	}
+	if code := postAPI(t, server, "/page", `{"Name": "usage"}`, &status); code != http.StatusOK || status.Pinned != "usage" {
+		t.Errorf("/page usage: %d, pinned %q", code, status.Pinned)
+	}
+	if code := postAPI(t, server, "/page/next", "", &status); code != http.StatusOK || status.Pinned != "temps" {
+		t.Errorf("/page/next while pinned: %d, pinned %q", code, status.Pinned)
+	}
+	if code := postAPI(t, server, "/page", `{"Name": "missing"}`, nil); code != http.StatusNotFound {
+		t.Errorf("unknown page: %d", code)
+	}
+	var resumed CarouselStatus
+	if code := postAPI(t, server, "/page", `{"Name": ""}`, &resumed); code != http.StatusOK || resumed.Pinned != "" {
+		t.Errorf("resume rotation: %d, pinned %q", code, resumed.Pinned)
+	}
+}
+
+func TestAPIRefusesWebPages(t *testing.T) {
+	server := newTestAPI(t)
+	request := func(method, path, contentType string, header map[string]string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(`{"Brightness": 10}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		if host, ok := header["Host"]; ok {
+			req.Host = host
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		header      map[string]string
+	}{
+		{"form post", http.MethodPost, "/pause", "text/plain", nil},
+		{"no content type", http.MethodPost, "/brightness", "", nil},
+		{"cross origin", http.MethodPost, "/pause", "application/json", map[string]string{"Origin": "http://example.com"}},
+		{"rebound host", http.MethodGet, "/status", "", map[string]string{"Host": "evil.example:9102"}},
+		{"rebound host post", http.MethodPost, "/clock", "application/json", map[string]string{"Host": "evil.example"}},
+	}
+	for _, tt := range tests {
+		if code := request(tt.method, tt.path, tt.contentType, tt.header); code != http.StatusForbidden {
+			t.Errorf("%s: %d, want %d", tt.name, code, http.StatusForbidden)
+		}
+	}
+	if daemonState.Paused() {
+		t.Errorf("a refused request paused the daemon")
+	}
+
+	for _, host := range []string{"localhost:9102", "127.0.0.1", "[::1]:9102"} {
+		if code := request(http.MethodGet, "/status", "", map[string]string{"Host": host}); code != http.StatusOK {
+			t.Errorf("Host %s: %d", host, code)
+		}
+	}
+	if code := request(http.MethodPost, "/pause", "application/json; charset=utf-8", nil); code != http.StatusOK {
+		t.Errorf("JSON with charset: %d", code)
+	}
+}
+
+func TestAPIOnUnixSocketAcceptsAnyHost(t *testing.T) {
+	newTestAPI(t)
+	dir, err := os.MkdirTemp("", "divoom-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "api.sock")
+	listener, err := listenAPI("unix:" + socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: newAPIHandler()}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	client := &http.Client{Transport: &http.Transport{
+		Dial: func(_, _ string) (net.Conn, error) { return net.Dial("unix", socket) },
+	}}
+	resp, err := client.Get("http://divoom-daemon/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /status over the socket: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// BackoffConfig controls how the daemon backs off from unreachable devices.
+type BackoffConfig struct {
+	FailureThreshold int      `json:"FailureThreshold"` // failures before the breaker opens
+	BaseDelay        Duration `json:"BaseDelay"`        // first open period
+	MaxDelay         Duration `json:"MaxDelay"`         // upper bound for the open period
+	ReportInterval   Duration `json:"ReportInterval"`   // how often to log that a device is still down
+}
+
+func (c *BackoffConfig) applyDefaults() {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 3
+	}
+	if c.BaseDelay.Duration <= 0 {
+		c.BaseDelay.Duration = 5 * time.Second
+	}
+	if c.MaxDelay.Duration <= 0 {
+		c.MaxDelay.Duration = 5 * time.Minute
+	}
+	if c.MaxDelay.Duration < c.BaseDelay.Duration {
+		c.MaxDelay.Duration = c.BaseDelay.Duration
+	}
+	if c.ReportInterval.Duration <= 0 {
+		c.ReportInterval.Duration = time.Minute
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// DeviceBreaker is a circuit breaker for one device. While closed every
+// tick is sent. After FailureThreshold consecutive failures it opens and
+// sends are skipped for an exponentially growing, jittered period; then a
+// single half-open attempt decides whether it closes again or reopens.
+type DeviceBreaker struct {
+	ip     string
+	config BackoffConfig
+
+	state      breakerState
+	failures   int
+	opens      int
+	openUntil  time.Time
+	downSince  time.Time
+	lastReport time.Time
+	lastError  error
+}
+
+// Allow reports whether a send should be attempted now.
+func (b *DeviceBreaker) Allow(now time.Time) bool {
+	switch b.state {
+	case breakerOpen:
+		if now.Before(b.openUntil) {
+			b.report(now)
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	default:
+		return true
+	}
+}
+
+// Success closes the breaker and logs if the device was down.
+func (b *DeviceBreaker) Success(now time.Time) {
+	if !b.downSince.IsZero() {
+		logger.Info("Device reachable again", "device", b.ip,
+			"downtime", formatDowntime(now.Sub(b.downSince)), "failures", b.failures)
+	}
+	b.state = breakerClosed
+	b.failures = 0
+	b.opens = 0
+	b.downSince = time.Time{}
+	b.lastError = nil
+}
+
+// Failure records a failed send. Only the first failure is logged in full;
+// afterwards the device is reported as unreachable every ReportInterval.
+func (b *DeviceBreaker) Failure(now time.Time, err error) {
+	b.failures++
+	b.lastError = err
+	if b.downSince.IsZero() {
+		b.downSince = now
+		b.lastReport = now
+		logger.Warn("Error sending data", "device", b.ip, "err", err)
+	}
+
+	if b.state == breakerHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.opens++
+		delay := b.backoffDelay()
+		b.state = breakerOpen
+		b.openUntil = now.Add(delay)
+		if b.opens == 1 {
+			logger.Warn("Device unreachable, pausing sends", "device", b.ip, "delay", delay.Round(time.Second).String())
+		}
+	}
+	b.report(now)
+}
+
+// backoffDelay doubles BaseDelay for every consecutive open period, caps it
+// at MaxDelay and adds up to ±20% jitter.
+func (b *DeviceBreaker) backoffDelay() time.Duration {
+	delay := b.config.BaseDelay.Duration
+	for i := 1; i < b.opens && delay < b.config.MaxDelay.Duration; i++ {
+		delay *= 2
+	}
+	if delay > b.config.MaxDelay.Duration {
+		delay = b.config.MaxDelay.Duration
+	}
+	jitter := time.Duration((rand.Float64()*0.4 - 0.2) * float64(delay))
+	return delay + jitter
+}
+
+func (b *DeviceBreaker) report(now time.Time) {
+	if b.downSince.IsZero() || now.Sub(b.lastReport) < b.config.ReportInterval.Duration {
+		return
+	}
+	b.lastReport = now
+	logger.Warn("Device unreachable", "device", b.ip, "downtime", formatDowntime(now.Sub(b.downSince)),
+		"failures", b.failures, "breaker", b.state.String(), "err", b.lastError)
+}
+
+func formatDowntime(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	minutes := int(d / time.Minute)
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+// DeviceBreakers keeps one breaker per device IP.
+type DeviceBreakers struct {
+	mu       sync.Mutex
+	config   BackoffConfig
+	breakers map[string]*DeviceBreaker
+}
+
+func newDeviceBreakers(config BackoffConfig) *DeviceBreakers {
+	config.applyDefaults()
+	return &DeviceBreakers{config: config, breakers: make(map[string]*DeviceBreaker)}
+}
+
+func (d *DeviceBreakers) For(ip string) *DeviceBreaker {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	b, ok := d.breakers[ip]
+	if !ok {
+		b = &DeviceBreaker{ip: ip, config: d.config}
+		d.breakers[ip] = b
+	}
+	return b
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	s, device := newTestDevice(t)
+	breaker := newDeviceBreakers(BackoffConfig{
+		FailureThreshold: 3,
+		BaseDelay:        Duration{10 * time.Second},
+		MaxDelay:         Duration{time.Minute},
+	}).For(device.DevicePrivateIP)
+	send := func(now time.Time) {
+		t.Helper()
+		if !breaker.Allow(now) {
+			t.Fatalf("send at %s not allowed", now.Format(time.TimeOnly))
+		}
+		if err := postDaemonJSON(device, []byte(`{"Command":"Channel/GetAllConf"}`)); err != nil {
+			breaker.Failure(now, err)
+		} else {
+			breaker.Success(now)
+		}
+	}
+
+	// The device answers, but rejects every command
+	s.SetErrorCode(1)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	send(now)
+	send(now)
+	if breaker.state != breakerClosed {
+		t.Fatalf("breaker %s after 2 of 3 failures", breaker.state)
+	}
+	send(now)
+	if breaker.state != breakerOpen {
+		t.Fatalf("breaker %s after 3 failures", breaker.state)
+	}
+	if breaker.Allow(now.Add(7 * time.Second)) {
+		t.Errorf("send allowed within the first open period")
+	}
+
+	// The half-open attempt fails and the breaker reopens for longer
+	now = now.Add(13 * time.Second)
+	send(now)
+	if breaker.state != breakerOpen {
+		t.Fatalf("breaker %s after a failed half-open attempt", breaker.state)
+	}
+	if open := breaker.openUntil.Sub(now); open < 16*time.Second || open > 24*time.Second {
+		t.Errorf("second open period %s, want 20s ±20%%", open)
+	}
+
+	// A successful half-open attempt closes it
+	s.SetErrorCode(0)
+	now = now.Add(25 * time.Second)
+	if !breaker.Allow(now) || breaker.state != breakerHalfOpen {
+		t.Fatalf("breaker %s after the open period", breaker.state)
+	}
+	breaker.Success(now)
+	if breaker.state != breakerClosed || breaker.failures != 0 || breaker.opens != 0 {
+		t.Errorf("after success: %s, %d failures, %d opens", breaker.state, breaker.failures, breaker.opens)
+	}
+
+	// Failures are counted from zero again
+	s.SetErrorCode(1)
+	send(now)
+	if breaker.state != breakerClosed {
+		t.Errorf("breaker %s after one new failure", breaker.state)
+	}
+}
+
+func TestBackoffDelayGrowth(t *testing.T) {
+	config := BackoffConfig{BaseDelay: Duration{5 * time.Second}, MaxDelay: Duration{time.Minute}}
+	config.applyDefaults()
+	b := &DeviceBreaker{config: config}
+
+	tests := []struct {
+		opens int
+		want  time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{4, 40 * time.Second},
+		{5, time.Minute},
+		{10, time.Minute},
+	}
+	for _, tt := range tests {
+		b.opens = tt.opens
+		for i := 0; i < 20; i++ {
+			delay := b.backoffDelay()
+			if delay < tt.want*8/10 || delay > tt.want*12/10 {
+				t.Errorf("open %d: delay %s, want %s ±20%%", tt.opens, delay, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestBackoffDefaults(t *testing.T) {
+	config := BackoffConfig{BaseDelay: Duration{time.Minute}, MaxDelay: Duration{time.Second}}
+	config.applyDefaults()
+	if config.FailureThreshold != 3 || config.MaxDelay.Duration != time.Minute || config.ReportInterval.Duration != time.Minute {
+		t.Errorf("defaults = %+v", config)
+	}
+}
+
+func TestFormatDowntime(t *testing.T) {
+	tests := map[time.Duration]string{
+		1400 * time.Millisecond:     "1s",
+		59 * time.Second:            "59s",
+		90 * time.Second:            "1 minute",
+		5*time.Minute + time.Second: "5 minutes",
+	}
+	for d, want := range tests {
+		if got := formatDowntime(d); got != want {
+			t.Errorf("formatDowntime(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
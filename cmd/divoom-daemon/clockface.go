@@ -0,0 +1,77 @@
+package main
+
+import (
+	"time"
+
+	"divoom-monitor/internal/divoom"
+)
+
+// ClockFaceConfig controls the check that the device shows the PC monitor
+// face, without which UpdatePCParaInfo data is not visible.
+type ClockFaceConfig struct {
+	Select        bool     `json:"Select"`        // switch back to the PC monitor face
+	CheckInterval Duration `json:"CheckInterval"` // default 1m
+}
+
+// ClockWatcher checks the selected channel and clock on startup and every
+// CheckInterval.
+type ClockWatcher struct {
+	config    ClockFaceConfig
+	lastCheck time.Time
+	away      bool
+}
+
+func newClockWatcher(config ClockFaceConfig) *ClockWatcher {
+	if config.CheckInterval.Duration <= 0 {
+		config.CheckInterval.Duration = time.Minute
+	}
+	return &ClockWatcher{config: config}
+}
+
+// Check queries the device when a check is due. It logs when the user
+// switched to another channel or clock and, if configured to, selects the
+// PC monitor face again. A failed query is only logged, so the data is still
+// sent; the query is tried again at the next check. Only a failed switch
+// back is returned.
+func (w *ClockWatcher) Check(device DaemonDevice, now time.Time) error {
+	if !w.lastCheck.IsZero() && now.Sub(w.lastCheck) < w.config.CheckInterval.Duration {
+		return nil
+	}
+	w.lastCheck = now
+
+	client := deviceClient()
+	conf, err := client.GetAllConf(device.DevicePrivateIP)
+	if err != nil {
+		logger.Warn("Could not check the clock face", "device", device.DevicePrivateIP, "err", err)
+		return nil
+	}
+	channel, err := client.GetChannel(device.DevicePrivateIP)
+	if err != nil {
+		logger.Warn("Could not check the clock face", "device", device.DevicePrivateIP, "err", err)
+		return nil
+	}
+
+	if channel == divoom.ChannelFaces && conf.CurClockId == divoom.PCMonitorClockId {
+		if w.away {
+			logger.Info("Device shows the PC monitor face again", "device", device.DevicePrivateIP)
+			w.away = false
+		}
+		return nil
+	}
+
+	if !w.away {
+		logger.Warn("Device switched away from the PC monitor face", "device", device.DevicePrivateIP,
+			"channel", channel, "clock", conf.CurClockId)
+		w.away = true
+	}
+	if !w.config.Select {
+		return nil
+	}
+	if err := client.SetClock(device.DevicePrivateIP, divoom.PCMonitorClockId); err != nil {
+		return err
+	}
+	logger.Info("Selected the PC monitor face", "device", device.DevicePrivateIP, "clock", divoom.PCMonitorClockId)
+	w.away = false
+	daemonPayloads.ForgetDevice(device.DevicePrivateIP)
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"divoom-monitor/internal/divoom"
+)
+
+func TestClockWatcherSelectsPCMonitorFace(t *testing.T) {
+	s, device := newTestDevice(t)
+	client := deviceClient()
+	watcher := newClockWatcher(ClockFaceConfig{Select: true, CheckInterval: Duration{time.Minute}})
+	start := time.Now()
+
+	if err := client.SetClock(device.DevicePrivateIP, 12); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Check(device, start); err != nil {
+		t.Fatal(err)
+	}
+	if clock := s.State().CurClockId; clock != divoom.PCMonitorClockId {
+		t.Errorf("clock %d, want %d", clock, divoom.PCMonitorClockId)
+	}
+
+	// Another channel counts as away too, but only once the check is due
+	if err := client.SetChannel(device.DevicePrivateIP, divoom.ChannelCloud); err != nil {
+		t.Fatal(err)
+	}
+	watcher.Check(device, start.Add(30*time.Second))
+	if index := s.State().SelectIndex; index != divoom.ChannelCloud {
+		t.Errorf("channel %d changed before the check was due", index)
+	}
+	watcher.Check(device, start.Add(time.Minute))
+	if index := s.State().SelectIndex; index != divoom.ChannelFaces {
+		t.Errorf("channel %d, want the faces channel", index)
+	}
+}
+
+func TestClockWatcherOnlyLogs(t *testing.T) {
+	s, device := newTestDevice(t)
+	watcher := newClockWatcher(ClockFaceConfig{})
+	if err := deviceClient().SetClock(device.DevicePrivateIP, 12); err != nil {
+		t.Fatal(err)
+	}
+	s.Device.Reset()
+
+	if err := watcher.Check(device, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !watcher.away {
+		t.Errorf("switch to clock 12 not noticed")
+	}
+	if n := len(s.CommandsNamed("Channel/SetClockSelectId")); n != 0 {
+		t.Errorf("clock selected %d times without Select", n)
+	}
+}
+
+func TestClockWatcherQueryFailureDoesNotStopSends(t *testing.T) {
+	s, device := newTestDevice(t)
+	watcher := newClockWatcher(ClockFaceConfig{Select: true})
+	start := time.Now()
+
+	s.SetErrorCode(1)
+	if err := watcher.Check(device, start); err != nil {
+		t.Errorf("failed query returned %v", err)
+	}
+	if n := len(s.CommandsNamed("Channel/SetClockSelectId")); n != 0 {
+		t.Errorf("clock selected %d times without knowing the current one", n)
+	}
+
+	// The next check queries again and switches back
+	s.SetErrorCode(0)
+	deviceClient().SetClock(device.DevicePrivateIP, 12)
+	if err := watcher.Check(device, start.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if clock := s.State().CurClockId; clock != divoom.PCMonitorClockId {
+		t.Errorf("clock %d, want %d", clock, divoom.PCMonitorClockId)
+	}
+}
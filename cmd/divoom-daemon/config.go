@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"divoom-monitor/internal/divoom"
+	"divoom-monitor/internal/pixel"
+)
+
+// Display modes selected with the config file's Mode key. Without a Mode the
+// device model decides, see defaultMode.
+const (
+	modePCMonitor = "pcmonitor" // Device/UpdatePCParaInfo on clock 625
+	modeText      = "text"      // Draw/SendHttpText lines, e.g. for Pixoo64
+	modeGraph     = "graph"     // Draw/SendHttpGif history graphs
+	modeScreen    = "screen"    // Draw/SendHttpGif custom dashboard
+)
+
+// DisplayConfig selects what is drawn on the device. It is used at the top
+// level of the configuration file and for every carousel page.
+type DisplayConfig struct {
+	Mode   string       `json:"Mode"`
+	Layout []string     `json:"Layout"`
+	Text   []TextLine   `json:"Text"`
+	Graph  GraphConfig  `json:"Graph"`
+	Screen pixel.Screen `json:"Screen"`
+}
+
+func (d *DisplayConfig) validate() error {
+	switch d.Mode {
+	case "", modePCMonitor, modeText, modeGraph, modeScreen:
+	default:
+		return fmt.Errorf("unknown display mode %q", d.Mode)
+	}
+	if err := validateLayout(d.Layout); err != nil {
+		return err
+	}
+	if err := validateTextLines(d.Text); err != nil {
+		return err
+	}
+	if err := d.Graph.validate(); err != nil {
+		return fmt.Errorf("graph: %v", err)
+	}
+	if err := validateScreen(&d.Screen); err != nil {
+		return fmt.Errorf("screen: %v", err)
+	}
+	return nil
+}
+
+// DaemonConfig is the optional JSON configuration file passed with --config.
+type DaemonConfig struct {
+	DisplayConfig
+
+	DiscoveryURL string                     `json:"DiscoveryURL"`
+	DevicePort   int                        `json:"DevicePort"`
+	Alerts       []AlertRule                `json:"Alerts"`
+	Notifiers    []NotifierConfig           `json:"Notifiers"`
+	Backoff      BackoffConfig              `json:"Backoff"`
+	Dedup        DedupConfig                `json:"Dedup"`
+	Pages        []PageConfig               `json:"Pages"`
+	Schedule     ScheduleConfig             `json:"Schedule"`
+	ClockFace    ClockFaceConfig            `json:"ClockFace"`
+	Smoothing    map[string]SmoothingConfig `json:"Smoothing"`
+	Stats        StatsConfig                `json:"Stats"`
+}
+
+// Duration is a time.Duration that is written as "30s", "5m" etc. in JSON.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %v", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func loadDaemonConfig(path string) (*DaemonConfig, error) {
+	config := &DaemonConfig{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("parse %s: %v", path, err)
+		}
+	}
+
+	if err := config.DisplayConfig.validate(); err != nil {
+		return nil, err
+	}
+	if err := validatePages(config.Pages); err != nil {
+		return nil, err
+	}
+	for i := range config.Alerts {
+		if err := config.Alerts[i].validate(); err != nil {
+			return nil, fmt.Errorf("alert %d: %v", i+1, err)
+		}
+		if page := config.Alerts[i].Page; page != "" && !hasPage(config.Pages, page) {
+			return nil, fmt.Errorf("alert %d: unknown page %q", i+1, page)
+		}
+	}
+	if err := config.Schedule.validate(); err != nil {
+		return nil, fmt.Errorf("schedule: %v", err)
+	}
+	for name, smoothing := range config.Smoothing {
+		if _, ok := (DaemonHardwareData{}).Metric(name); !ok {
+			return nil, fmt.Errorf("smoothing: unknown metric %q", name)
+		}
+		if err := smoothing.validate(); err != nil {
+			return nil, fmt.Errorf("smoothing %s: %v", name, err)
+		}
+	}
+	for i := range config.Notifiers {
+		if err := config.Notifiers[i].validate(); err != nil {
+			return nil, fmt.Errorf("notifier %d: %v", i+1, err)
+		}
+	}
+	return config, nil
+}
+
+// pages returns the carousel pages, or a single page with the top-level
+// display settings when none are configured.
+func (c *DaemonConfig) pages() []PageConfig {
+	if len(c.Pages) == 0 {
+		return []PageConfig{{Name: "default", DisplayConfig: c.DisplayConfig}}
+	}
+	return c.Pages
+}
+
+// applyEndpointConfig takes the discovery URL and device port from the
+// config file unless a flag in fs or an environment variable already set them.
+func applyEndpointConfig(endpoints *divoom.Endpoints, config *DaemonConfig, fs *flag.FlagSet) {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if config.DiscoveryURL != "" && !set["discovery-url"] && os.Getenv(divoom.EnvDiscoveryURL) == "" {
+		endpoints.DiscoveryURL = config.DiscoveryURL
+	}
+	if config.DevicePort != 0 && !set["device-port"] && os.Getenv(divoom.EnvDevicePort) == "" {
+		endpoints.DevicePort = config.DevicePort
+	}
+}
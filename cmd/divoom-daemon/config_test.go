@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"divoom-monitor/internal/divoom"
+)
+
+func TestEndpointPrecedence(t *testing.T) {
+	config := &DaemonConfig{DiscoveryURL: "http://config.example/devices", DevicePort: 8000}
+	tests := []struct {
+		name     string
+		envURL   string
+		envPort  string
+		args     []string
+		wantURL  string
+		wantPort int
+	}{
+		{"config", "", "", nil, "http://config.example/devices", 8000},
+		{"environment over config", "http://env.example/devices", "8001", nil, "http://env.example/devices", 8001},
+		{"flags over environment", "http://env.example/devices", "8001",
+			[]string{"--discovery-url", "http://flag.example/devices", "--device-port", "8002"},
+			"http://flag.example/devices", 8002},
+		{"flag over config", "", "", []string{"--device-port", "8002"}, "http://config.example/devices", 8002},
+	}
+	for _, tt := range tests {
+		t.Setenv(divoom.EnvDiscoveryURL, tt.envURL)
+		t.Setenv(divoom.EnvDevicePort, tt.envPort)
+		endpoints := divoom.EndpointsFromEnv()
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		endpoints.RegisterFlags(fs)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+
+		applyEndpointConfig(&endpoints, config, fs)
+		if err := endpoints.Validate(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if endpoints.DiscoveryURL != tt.wantURL || endpoints.DevicePort != tt.wantPort {
+			t.Errorf("%s: %s port %d, want %s port %d", tt.name,
+				endpoints.DiscoveryURL, endpoints.DevicePort, tt.wantURL, tt.wantPort)
+		}
+	}
+}
+
+func TestEndpointInvalidEnvPort(t *testing.T) {
+	t.Setenv(divoom.EnvDiscoveryURL, "")
+	t.Setenv(divoom.EnvDevicePort, "eighty")
+	endpoints := divoom.EndpointsFromEnv()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	endpoints.RegisterFlags(fs)
+
+	// The environment is set, so the config file's port does not hide the mistake
+	applyEndpointConfig(&endpoints, &DaemonConfig{DevicePort: 8000}, fs)
+	if err := endpoints.Validate(); err == nil {
+		t.Errorf("invalid %s accepted", divoom.EnvDevicePort)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DedupConfig controls skipping of unchanged UpdatePCParaInfo payloads.
+type DedupConfig struct {
+	Disabled bool     `json:"Disabled"`
+	MaxAge   Duration `json:"MaxAge"` // resend an unchanged payload after this long
+}
+
+type payloadEntry struct {
+	body   []byte
+	sentAt time.Time
+}
+
+// PayloadCache remembers the last payload each device/LCD acknowledged so
+// identical payloads are not posted again until MaxAge has passed.
+type PayloadCache struct {
+	mu      sync.Mutex
+	config  DedupConfig
+	entries map[string]payloadEntry
+}
+
+var daemonPayloads = newPayloadCache(DedupConfig{})
+
+func newPayloadCache(config DedupConfig) *PayloadCache {
+	c := &PayloadCache{entries: make(map[string]payloadEntry)}
+	c.Configure(config)
+	return c
+}
+
+func (c *PayloadCache) Configure(config DedupConfig) {
+	if config.MaxAge.Duration <= 0 {
+		config.MaxAge.Duration = time.Minute
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config = config
+}
+
+// Unchanged reports whether body equals the last acknowledged payload for
+// key and is still younger than MaxAge.
+func (c *PayloadCache) Unchanged(key string, body []byte, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.config.Disabled {
+		return false
+	}
+	entry, ok := c.entries[key]
+	return ok && bytes.Equal(entry.body, body) && now.Sub(entry.sentAt) < c.config.MaxAge.Duration
+}
+
+// Acknowledge records body as successfully sent for key.
+func (c *PayloadCache) Acknowledge(key string, body []byte, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = payloadEntry{body: body, sentAt: now}
+}
+
+// ForgetDevice drops every entry for a device, e.g. after another command
+// replaced what is on its screen.
+func (c *PayloadCache) ForgetDevice(ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, ip+"/") {
+			delete(c.entries, key)
+		}
+	}
+}
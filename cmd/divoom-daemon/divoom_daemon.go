@@ -1,327 +1,721 @@
-package main
-
-import (
-	"bytes"
-	"context"
-	"encoding/json"
-	"flag"
-	"fmt"
-	"io"
-	"log"
-	"log/syslog"
-	"net/http"
-	"os"
-	"os/exec"
-	"os/signal"
-	"strconv"
-	"strings"
-	"syscall"
-	"time"
-
-	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/host"
-	"github.com/shirou/gopsutil/v3/mem"
-)
-
-var version = "dev" // Set by build flags
-
-type DaemonDeviceList struct {
-	TotalData  int            `json:"TotalData"`
-	DeviceList []DaemonDevice `json:"DeviceList"`
-}
-
-type DaemonDevice struct {
-	DeviceName      string `json:"DeviceName"`
-	DeviceId        int    `json:"DeviceId"`
-	DevicePrivateIP string `json:"DevicePrivateIP"`
-	DeviceMac       string `json:"DeviceMac"`
-	Hardware        int    `json:"Hardware"`
-}
-
-type DaemonHardwareData struct {
-	CpuUsage    int
-	GpuUsage    int
-	CpuTemp     int
-	GpuTemp     int
-	MemoryUsage int
-	DiskTemp    int
-}
-
-type DaemonPCMonitorPayload struct {
-	Command    string                        `json:"Command"`
-	ScreenList []DaemonPCMonitorScreenItem `json:"ScreenList"`
-}
-
-type DaemonPCMonitorScreenItem struct {
-	LcdId    int      `json:"LcdId"`
-	DispData []string `json:"DispData"`
-}
-
-var (
-	daemonHttpClient = &http.Client{Timeout: 10 * time.Second}
-	logger           *log.Logger
-)
-
-func main() {
-	var showVersion = flag.Bool("version", false, "Show version information")
-	var showHelp = flag.Bool("help", false, "Show help information")
-	var deviceIP = flag.String("device", "", "Device IP address (auto-detect if not specified)")
-	var lcdId = flag.Int("lcd", 0, "LCD ID for TimeGate devices (0-4)")
-	var interval = flag.Int("interval", 3, "Update interval in seconds")
-	var useSyslog = flag.Bool("syslog", false, "Use syslog for logging")
-	var logFile = flag.String("logfile", "", "Log file path (default: stderr)")
-	flag.Parse()
-
-	if *showVersion {
-		fmt.Printf("divoom-pcmonitor Daemon version %s\n", version)
-		return
-	}
-
-	if *showHelp {
-		fmt.Println("divoom-pcmonitor Daemon - Background PC monitoring for Divoom devices")
-		fmt.Printf("Version: %s\n\n", version)
-		fmt.Println("Usage:")
-		fmt.Println("  divoom-daemon [flags]")
-		fmt.Println("\nFlags:")
-		flag.PrintDefaults()
-		fmt.Println("\nSystemd service:")
-		fmt.Println("  sudo systemctl enable divoom-monitor")
-		fmt.Println("  sudo systemctl start divoom-monitor")
-		return
-	}
-
-	// Setup logging
-	setupLogging(*useSyslog, *logFile)
-	
-	logger.Printf("Starting divoom-pcmonitor Daemon v%s", version)
-	
-	// Find device
-	var device *DaemonDevice
-	if *deviceIP != "" {
-		device = &DaemonDevice{DevicePrivateIP: *deviceIP}
-		logger.Printf("Using specified device IP: %s", *deviceIP)
-	} else {
-		logger.Println("Auto-detecting Divoom device...")
-		devices, err := findDaemonDevices()
-		if err != nil {
-			logger.Fatalf("Error finding devices: %v", err)
-		}
-		if len(devices) == 0 {
-			logger.Fatal("No Divoom devices found on network")
-		}
-		device = &devices[0]
-		logger.Printf("Found device: %s (%s)", device.DeviceName, device.DevicePrivateIP)
-	}
-
-	// Setup signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
-
-	// Start monitoring loop
-	ticker := time.NewTicker(time.Duration(*interval) * time.Second)
-	defer ticker.Stop()
-
-	logger.Printf("Starting monitoring loop (interval: %ds, LCD: %d)", *interval, *lcdId)
-
-	for {
-		select {
-		case <-ticker.C:
-			data := getDaemonHardwareData()
-			if err := sendDaemonDataToDevice(*device, data, *lcdId); err != nil {
-				logger.Printf("Error sending data: %v", err)
-			} else {
-				logger.Printf("Sent: CPU:%d%% %d°C GPU:%d%% %d°C MEM:%d%% DSK:%d°C",
-					data.CpuUsage, data.CpuTemp, data.GpuUsage, data.GpuTemp,
-					data.MemoryUsage, data.DiskTemp)
-			}
-
-		case sig := <-sigChan:
-			logger.Printf("Received signal: %v", sig)
-			if sig == syscall.SIGHUP {
-				logger.Println("Reloading configuration...")
-				// Reload logic here if needed
-				continue
-			}
-			logger.Println("Shutting down gracefully...")
-			return
-		}
-	}
-}
-
-func setupLogging(useSyslog bool, logFile string) {
-	if useSyslog {
-		syslogger, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "divoom-daemon")
-		if err != nil {
-			log.Fatalf("Failed to connect to syslog: %v", err)
-		}
-		logger = log.New(syslogger, "", 0)
-	} else if logFile != "" {
-		file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-		if err != nil {
-			log.Fatalf("Failed to open log file: %v", err)
-		}
-		logger = log.New(file, "", log.LstdFlags)
-	} else {
-		logger = log.New(os.Stderr, "", log.LstdFlags)
-	}
-}
-
-func findDaemonDevices() ([]DaemonDevice, error) {
-	resp, err := daemonHttpClient.Get("http://app.divoom-gz.com/Device/ReturnSameLANDevice")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var deviceList DaemonDeviceList
-	if err := json.Unmarshal(body, &deviceList); err != nil {
-		return nil, err
-	}
-
-	return deviceList.DeviceList, nil
-}
-
-func getDaemonHardwareData() DaemonHardwareData {
-	data := DaemonHardwareData{}
-
-	// CPU Usage - use 100ms interval instead of 1 second
-	cpuPercent, err := cpu.Percent(100*time.Millisecond, false)
-	if err == nil && len(cpuPercent) > 0 {
-		data.CpuUsage = int(cpuPercent[0])
-	}
-
-	// Temperature sensors
-	temps, err := host.SensorsTemperatures()
-	if err == nil {
-		// CPU Temperature
-		for _, temp := range temps {
-			if strings.Contains(strings.ToLower(temp.SensorKey), "cpu") ||
-				strings.Contains(strings.ToLower(temp.SensorKey), "package") ||
-				strings.Contains(strings.ToLower(temp.SensorKey), "core") {
-				data.CpuTemp = int(temp.Temperature)
-				break
-			}
-		}
-
-		// Disk Temperature
-		for _, temp := range temps {
-			if strings.Contains(strings.ToLower(temp.SensorKey), "nvme") ||
-				strings.Contains(strings.ToLower(temp.SensorKey), "sda") ||
-				strings.Contains(strings.ToLower(temp.SensorKey), "disk") {
-				data.DiskTemp = int(temp.Temperature)
-				break
-			}
-		}
-	}
-
-	// Memory Usage
-	vmStat, err := mem.VirtualMemory()
-	if err == nil {
-		data.MemoryUsage = int(vmStat.UsedPercent)
-	}
-
-	// GPU data
-	if gpuData := getNvidiaGPUData(); gpuData != nil {
-		data.GpuUsage = gpuData.Usage
-		data.GpuTemp = gpuData.Temp
-	} else {
-		data.GpuUsage = 0
-		data.GpuTemp = 0
-	}
-
-	return data
-}
-
-func getNvidiaGPUData() *struct{ Usage, Temp int } {
-	// Check if nvidia-smi is available first
-	if _, err := exec.LookPath("nvidia-smi"); err != nil {
-		return nil
-	}
-	
-	// Try to execute nvidia-smi to get GPU data with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-	defer cancel()
-	
-	cmd := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=utilization.gpu,temperature.gpu", "--format=csv,noheader,nounits")
-	cmd.Env = append(os.Environ(), "HOME=/tmp")
-	output, err := cmd.Output()
-	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			logger.Printf("GPU detection timeout")
-		} else {
-			logger.Printf("GPU detection failed: %v", err)
-		}
-		return nil
-	}
-
-	// Parse the output
-	outputStr := strings.TrimSpace(string(output))
-	lines := strings.Split(outputStr, "\n")
-	if len(lines) == 0 {
-		logger.Printf("GPU: No output lines from nvidia-smi")
-		return nil
-	}
-
-	// Get first GPU data
-	parts := strings.Split(lines[0], ", ")
-	if len(parts) != 2 {
-		logger.Printf("GPU: Unexpected output format: %s", lines[0])
-		return nil
-	}
-
-	usage, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
-	temp, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
-	
-	if err1 != nil || err2 != nil {
-		logger.Printf("GPU: Parse error - usage: %v, temp: %v", err1, err2)
-		return nil
-	}
-
-	logger.Printf("GPU: Detected - Usage: %d%%, Temp: %d°C", usage, temp)
-	return &struct{ Usage, Temp int }{Usage: usage, Temp: temp}
-}
-
-func sendDaemonDataToDevice(device DaemonDevice, data DaemonHardwareData, lcdId int) error {
-	// Format data according to Windows implementation
-	// DispData array: [CpuUse, GpuUse, CpuTemp, GpuTemp, MemUse, DiskTemp]
-	cpuUse := fmt.Sprintf("%d%%", data.CpuUsage)
-	gpuUse := fmt.Sprintf("%d%%", data.GpuUsage)
-	cpuTemp := fmt.Sprintf("%d°C", data.CpuTemp)
-	gpuTemp := fmt.Sprintf("%d°C", data.GpuTemp)
-	memUse := fmt.Sprintf("%d%%", data.MemoryUsage)
-	diskTemp := fmt.Sprintf("%d°C", data.DiskTemp)
-
-	payload := DaemonPCMonitorPayload{
-		Command: "Device/UpdatePCParaInfo",
-		ScreenList: []DaemonPCMonitorScreenItem{
-			{
-				LcdId:    lcdId,
-				DispData: []string{cpuUse, gpuUse, cpuTemp, gpuTemp, memUse, diskTemp},
-			},
-		},
-	}
-
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-
-	url := fmt.Sprintf("http://%s:80/post", device.DevicePrivateIP)
-	resp, err := daemonHttpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("POST request failed: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("device returned status %d: %s", resp.StatusCode, string(respBody))
-	}
-
-	return nil
-}
\ No newline at end of file
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"divoom-monitor/internal/divoom"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+var version = "dev" // Set by build flags
+
+type DaemonDeviceList struct {
+	TotalData  int            `json:"TotalData"`
+	DeviceList []DaemonDevice `json:"DeviceList"`
+}
+
+type DaemonDevice struct {
+	DeviceName      string `json:"DeviceName"`
+	DeviceId        int    `json:"DeviceId"`
+	DevicePrivateIP string `json:"DevicePrivateIP"`
+	DeviceMac       string `json:"DeviceMac"`
+	Hardware        int    `json:"Hardware"`
+}
+
+type DaemonHardwareData struct {
+	CpuUsage    int
+	GpuUsage    int
+	CpuTemp     int
+	GpuTemp     int
+	MemoryUsage int
+	DiskTemp    int
+}
+
+// daemonMetricNames lists the metrics in DispData order.
+var daemonMetricNames = []string{"CpuUsage", "GpuUsage", "CpuTemp", "GpuTemp", "MemoryUsage", "DiskTemp"}
+
+// Metric returns the value of a field by name, e.g. "CpuTemp".
+func (d DaemonHardwareData) Metric(name string) (float64, bool) {
+	switch name {
+	case "CpuUsage":
+		return float64(d.CpuUsage), true
+	case "GpuUsage":
+		return float64(d.GpuUsage), true
+	case "CpuTemp":
+		return float64(d.CpuTemp), true
+	case "GpuTemp":
+		return float64(d.GpuTemp), true
+	case "MemoryUsage":
+		return float64(d.MemoryUsage), true
+	case "DiskTemp":
+		return float64(d.DiskTemp), true
+	}
+	return 0, false
+}
+
+// SetMetric sets a field by name, rounding to whole units.
+func (d *DaemonHardwareData) SetMetric(name string, value float64) {
+	v := int(math.Round(value))
+	switch name {
+	case "CpuUsage":
+		d.CpuUsage = v
+	case "GpuUsage":
+		d.GpuUsage = v
+	case "CpuTemp":
+		d.CpuTemp = v
+	case "GpuTemp":
+		d.GpuTemp = v
+	case "MemoryUsage":
+		d.MemoryUsage = v
+	case "DiskTemp":
+		d.DiskTemp = v
+	}
+}
+
+type DaemonPCMonitorPayload struct {
+	Command    string                      `json:"Command"`
+	ScreenList []DaemonPCMonitorScreenItem `json:"ScreenList"`
+}
+
+type DaemonPCMonitorScreenItem struct {
+	LcdId    int      `json:"LcdId"`
+	DispData []string `json:"DispData"`
+}
+
+type DaemonCommandPayload struct {
+	Command string `json:"Command"`
+}
+
+type DaemonClockPayload struct {
+	Command string `json:"Command"`
+	ClockId int    `json:"ClockId"`
+}
+
+type DaemonTextPayload struct {
+	Command    string `json:"Command"`
+	TextId     int    `json:"TextId"`
+	X          int    `json:"x"`
+	Y          int    `json:"y"`
+	Dir        int    `json:"dir"`
+	Font       int    `json:"font"`
+	TextWidth  int    `json:"TextWidth"`
+	Speed      int    `json:"speed"`
+	TextString string `json:"TextString"`
+	Color      string `json:"color"`
+	Align      int    `json:"align"`
+}
+
+var (
+	daemonHttpClient = &http.Client{Timeout: 10 * time.Second}
+	daemonEndpoints  = divoom.EndpointsFromEnv()
+	// daemonDryRun is set by --dry-run, when device answers are stubbed
+	daemonDryRun bool
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "install-user-service" {
+		if err := installUserService(os.Args[2:]); err != nil {
+			if err != flag.ErrHelp {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+
+	var showVersion = flag.Bool("version", false, "Show version information")
+	var showHelp = flag.Bool("help", false, "Show help information")
+	var deviceIP = flag.String("device", "", "Device IP address (auto-detect if not specified)")
+	var lcdId = flag.Int("lcd", 0, "LCD ID for TimeGate devices (0-4)")
+	var interval = flag.Int("interval", 3, "Update interval in seconds")
+	var useSyslog = flag.Bool("syslog", false, "Use syslog for logging")
+	var logFile = flag.String("logfile", "", "Log file path (default: stderr), reopened on SIGUSR1")
+	var logLevel = flag.String("log-level", "info", "Log level: debug, info, warn or error")
+	var logFormat = flag.String("log-format", "text", "Log format: text or json")
+	var metricsAddr = flag.String("metrics-addr", "", "Serve Prometheus metrics on this address (e.g. :9101)")
+	var configFile = flag.String("config", "", "Configuration file (JSON, default: $XDG_CONFIG_HOME/divoom-monitor/config.json if present)")
+	daemonEndpoints.RegisterFlags(flag.CommandLine)
+	var apiAddr = flag.String("api-addr", "", "Serve the control API on a loopback address or unix:/path socket")
+	var recordFile = flag.String("record", "", "Append the collected data and sent payloads of each tick to this file (JSON lines)")
+	var replayFile = flag.String("replay", "", "Replay the data of a recording instead of collecting it")
+	var replaySpeed = flag.Float64("replay-speed", 1, "Replay speed, e.g. 10 for ten times faster")
+	var dryRun = flag.Bool("dry-run", false, "Print the payloads that would be posted to the device instead of sending them")
+	var pauseWhenLocked = flag.Bool("pause-when-locked", false, "Pause updates while the graphical session is locked")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("divoom-pcmonitor Daemon version %s\n", version)
+		return
+	}
+
+	if *showHelp {
+		fmt.Println("divoom-pcmonitor Daemon - Background PC monitoring for Divoom devices")
+		fmt.Printf("Version: %s\n\n", version)
+		fmt.Println("Usage:")
+		fmt.Println("  divoom-daemon [flags]")
+		fmt.Println("  divoom-daemon install-user-service [--force] [-- daemon flags]")
+		fmt.Println("\nFlags:")
+		flag.PrintDefaults()
+		fmt.Println("\nSystemd service:")
+		fmt.Println("  sudo systemctl enable divoom-monitor")
+		fmt.Println("  sudo systemctl start divoom-monitor")
+		fmt.Println("\nUser service (runs in your session, pauses while it is locked):")
+		fmt.Println("  divoom-daemon install-user-service")
+		fmt.Println("  systemctl --user enable --now divoom-monitor")
+		return
+	}
+
+	// Setup logging
+	logOutput, err := setupLogging(*useSyslog, *logFile, *logLevel, *logFormat)
+	if err != nil {
+		fatal("Error setting up logging", "err", err)
+	}
+
+	logger.Info("Starting divoom-pcmonitor Daemon", "version", version)
+
+	configPath := *configFile
+	if configPath == "" {
+		configPath = defaultConfigFile()
+	}
+	if configPath != "" {
+		logger.Info("Using configuration", "file", configPath)
+	}
+	config, err := loadDaemonConfig(configPath)
+	if err != nil {
+		fatal("Error loading config", "err", err)
+	}
+	applyEndpointConfig(&daemonEndpoints, config, flag.CommandLine)
+	if err := daemonEndpoints.Validate(); err != nil {
+		fatal("Invalid endpoints", "err", err)
+	}
+	daemonDryRun = *dryRun
+	if *dryRun {
+		daemonHttpClient.Transport = &divoom.DryRunTransport{Out: os.Stdout}
+		logger.Info("Dry run: payloads are printed instead of sent")
+	}
+	alerts := newAlertManager(config.Alerts)
+	notifier := newNotifier(config.Notifiers)
+	breakers := newDeviceBreakers(config.Backoff)
+	daemonPayloads.Configure(config.Dedup)
+	pipeline := newMetricPipeline(config.Smoothing, config.Stats)
+	schedule := newScheduler(config.Schedule)
+	clockFace := newClockWatcher(config.ClockFace)
+	alertDisplay := &AlertDisplay{}
+
+	// Dry runs and replays leave the state of the real daemon alone
+	var stateFile *StateFile
+	var saved *SavedState
+	if !*dryRun && *replayFile == "" {
+		stateFile, saved, err = openStateFile()
+		if err != nil {
+			logger.Warn("State is not kept across restarts", "err", err)
+		}
+	}
+
+	// Find device
+	var device *DaemonDevice
+	if *deviceIP != "" {
+		device = &DaemonDevice{DevicePrivateIP: *deviceIP}
+		logger.Info("Using specified device", "device", *deviceIP)
+	} else {
+		logger.Info("Auto-detecting Divoom device")
+		daemonState.autoDetect = true
+		devices, err := findDaemonDevices()
+		switch {
+		case *dryRun && (err != nil || len(devices) == 0):
+			// Payloads can be tuned without a device on the network
+			logger.Info("No device found, using a placeholder for the dry run", "device", divoom.DryRunIP)
+			devices = []DaemonDevice{{DeviceName: "Dry run", DevicePrivateIP: divoom.DryRunIP}}
+		case (err != nil || len(devices) == 0) && saved != nil && saved.Device.DevicePrivateIP != "":
+			logger.Warn("Discovery found no device, using the last one", "device", saved.Device.DevicePrivateIP, "err", err)
+			devices = []DaemonDevice{saved.Device}
+		case err != nil:
+			fatal("Error finding devices", "err", err)
+		case len(devices) == 0:
+			fatal("No Divoom devices found on network")
+		}
+		device = &devices[0]
+		// Keep the device last used, e.g. one chosen through the control API
+		for i := range devices {
+			if saved != nil && devices[i].DeviceId != 0 && devices[i].DeviceId == saved.Device.DeviceId {
+				device = &devices[i]
+			}
+		}
+		logger.Info("Found device", "name", device.DeviceName, "device", device.DevicePrivateIP,
+			"model", divoom.ModelName(device.Hardware, device.DeviceName))
+	}
+	if saved != nil && sameDevice(saved.Device, *device) {
+		lcdSet := false
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "lcd" {
+				lcdSet = true
+			}
+		})
+		if !lcdSet {
+			*lcdId = saved.LcdId
+		}
+		if saved.Paused {
+			logger.Info("Updates stay paused as before the restart")
+			daemonState.SetPaused(true)
+		}
+		daemonState.SetDimRestore(saved.DimRestore)
+	}
+	if err := checkLcd(*device, *lcdId); err != nil {
+		fatal("Invalid LCD", "err", err)
+	}
+	pages, err := pagesForDevice(*device, config.pages())
+	if err != nil {
+		fatal("Pages do not suit the device", "err", err)
+	}
+	daemonPages.Configure(pages)
+	// pagesModel is the model the pages were set up for
+	pagesModel := divoom.ModelName(device.Hardware, device.DeviceName)
+	daemonState.SetTarget(*device, *lcdId)
+
+	if *metricsAddr != "" {
+		if err := startMetricsServer(*metricsAddr); err != nil {
+			fatal("Failed to start metrics server", "err", err)
+		}
+	}
+	if *apiAddr != "" {
+		if err := startAPIServer(*apiAddr); err != nil {
+			fatal("Failed to start control API", "err", err)
+		}
+	}
+
+	// Setup signal handling for graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	if *recordFile != "" {
+		recorder, err := newRecorder(*recordFile)
+		if err != nil {
+			fatal("Cannot record", "err", err)
+		}
+		daemonRecorder = recorder
+		defer daemonRecorder.Close()
+		logger.Info("Recording", "file", *recordFile)
+	}
+
+	// Start monitoring loop
+	var samples <-chan DaemonSample
+	if *replayFile != "" {
+		samples, err = replayDaemonSamples(*replayFile, *replaySpeed)
+		if err != nil {
+			fatal("Cannot replay", "err", err)
+		}
+		logger.Info("Replaying", "file", *replayFile, "speed", *replaySpeed)
+	} else {
+		samples = collectDaemonSamples(time.Duration(*interval) * time.Second)
+	}
+
+	logger.Info("Starting monitoring loop", "interval", (time.Duration(*interval) * time.Second).String(), "lcd", *lcdId, "pages", len(config.pages()))
+	service := newServiceNotifier()
+	service.Ready(daemonStatusLine())
+	stopWatchdog := service.StartWatchdog(time.Duration(*interval) * time.Second)
+	defer stopWatchdog()
+
+	var sessionLock *SessionLock
+	if *pauseWhenLocked {
+		sessionLock = newSessionLock()
+	}
+
+	// shownMode is the display mode of the page last sent to the device
+	shownMode := ""
+	for {
+		service.Idle(time.Now())
+		select {
+		case sample, ok := <-samples:
+			service.Tick(time.Now())
+			if !ok {
+				logger.Info("Replay finished")
+				service.Stopping()
+				device, _ := daemonState.Target()
+				if err := schedule.Reset(device); err != nil {
+					logger.Warn("Error restoring display", "err", err)
+				}
+				return
+			}
+			service.Status(daemonStatusLine())
+			if err := stateFile.Save(daemonState.Saved()); err != nil {
+				logger.Warn("Error saving state, no longer saving it", "err", err)
+				stateFile = nil
+			}
+			// The sample's time drives smoothing, alerts, pages and the
+			// schedule; the breaker and clock face check keep to the wall
+			// clock, as they deal with the device
+			now, raw := sample.Time, sample.Data
+			data, values := pipeline.Process(raw, now)
+			daemonRecorder.Begin(now, raw, values)
+			daemonMetrics.RecordSample(data)
+			daemonPages.Add(values)
+			for _, t := range alerts.Evaluate(data, now) {
+				if t.Firing {
+					logger.Warn(t.String(), "alert", t.Rule.Name, "value", t.Value)
+				} else {
+					logger.Info(t.String(), "alert", t.Rule.Name, "value", t.Value)
+				}
+				notifier.Notify(t)
+			}
+			locked := false
+			if sessionLock != nil {
+				locked = sessionLock.Locked(time.Now())
+				if locked != daemonState.Locked() {
+					daemonState.SetLocked(locked)
+					if locked {
+						logger.Info("Session locked, pausing updates")
+					} else {
+						logger.Info("Session unlocked, resuming updates")
+					}
+				}
+			}
+
+			device, lcdId := daemonState.Target()
+			// The API or rediscovery may have switched to another model
+			if model := divoom.ModelName(device.Hardware, device.DeviceName); model != pagesModel {
+				pagesModel = model
+				if pages, err := pagesForDevice(device, config.pages()); err != nil {
+					logger.Warn("Pages do not suit the new device, keeping them", "model", model, "err", err)
+				} else {
+					daemonPages.Configure(pages)
+				}
+			}
+			breaker := breakers.For(device.DevicePrivateIP)
+			if !breaker.Allow(time.Now()) {
+				continue
+			}
+			// The schedule turns the display off and on again even while
+			// updates are paused
+			hold, err := schedule.Update(device, now)
+			if err != nil {
+				breaker.Failure(time.Now(), err)
+				continue
+			}
+			if hold || locked || daemonState.Paused() {
+				continue
+			}
+
+			// An alert with a Page pins that page instead of showing a banner
+			active, alertPage := alerts.Active(), ""
+			if active != nil && active.rule.Page != "" {
+				active, alertPage = nil, active.rule.Page
+			}
+			page := daemonPages.Select(now, alertPage)
+			// A dry run has no device whose clock face could be checked
+			if page.Mode == modePCMonitor && active == nil && !*dryRun {
+				if err := clockFace.Check(device, time.Now()); err != nil {
+					breaker.Failure(time.Now(), err)
+					continue
+				}
+			}
+
+			start := time.Now()
+			alerting, err := alertDisplay.Update(device, active)
+			sent := alerting
+			if !alerting && err == nil {
+				if err = switchDisplayMode(device, shownMode, page.Mode); err == nil {
+					shownMode = page.Mode
+					sent, err = page.Send(device, values, lcdId)
+				}
+			}
+			if !sent && err == nil {
+				daemonMetrics.RecordSkip()
+				continue
+			}
+			daemonMetrics.RecordSend(time.Since(start), err)
+			daemonState.RecordSend(err)
+			if err != nil {
+				breaker.Failure(time.Now(), err)
+				continue
+			}
+			breaker.Success(time.Now())
+			if !alerting {
+				logger.Debug("Sent", "device", device.DevicePrivateIP, "page", page.Name,
+					"cpu", data.CpuUsage, "cpu_temp", data.CpuTemp, "gpu", data.GpuUsage, "gpu_temp", data.GpuTemp,
+					"memory", data.MemoryUsage, "disk_temp", data.DiskTemp)
+			}
+
+		case sig := <-sigChan:
+			service.Busy(time.Now())
+			logger.Info("Received signal", "signal", sig.String())
+			if sig == syscall.SIGUSR1 {
+				if logOutput != nil {
+					if err := logOutput.Reopen(); err != nil {
+						logger.Error("Error reopening log file", "err", err)
+					}
+				}
+				continue
+			}
+			if sig == syscall.SIGUSR2 {
+				logger.Info("Showing next page", "page", daemonPages.Next())
+				continue
+			}
+			if sig == syscall.SIGHUP {
+				logger.Info("Reloading configuration")
+				service.Reloading()
+				device, _ := daemonState.Target()
+				newConfig, err := loadDaemonConfig(configPath)
+				var pages []PageConfig
+				if err == nil {
+					pages, err = pagesForDevice(device, newConfig.pages())
+				}
+				if err != nil {
+					logger.Error("Error reloading config, keeping previous", "err", err)
+					service.Ready(daemonStatusLine())
+					continue
+				}
+				if err := schedule.Reset(device); err != nil {
+					logger.Warn("Error restoring display", "err", err)
+				}
+				config = newConfig
+				alerts = newAlertManager(config.Alerts)
+				notifier = newNotifier(config.Notifiers)
+				breakers = newDeviceBreakers(config.Backoff)
+				daemonPayloads.Configure(config.Dedup)
+				pipeline = newMetricPipeline(config.Smoothing, config.Stats)
+				daemonPages.Configure(pages)
+				pagesModel = divoom.ModelName(device.Hardware, device.DeviceName)
+				schedule = newScheduler(config.Schedule)
+				clockFace = newClockWatcher(config.ClockFace)
+				service.Ready(daemonStatusLine())
+				continue
+			}
+			logger.Info("Shutting down gracefully")
+			service.Stopping()
+			device, _ := daemonState.Target()
+			if err := schedule.Reset(device); err != nil {
+				logger.Warn("Error restoring display", "err", err)
+			}
+			return
+		}
+	}
+}
+
+func findDaemonDevices() ([]DaemonDevice, error) {
+	resp, err := daemonHttpClient.Get(daemonEndpoints.DiscoveryURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var deviceList DaemonDeviceList
+	if err := json.Unmarshal(body, &deviceList); err != nil {
+		return nil, err
+	}
+
+	return deviceList.DeviceList, nil
+}
+
+func getDaemonHardwareData() DaemonHardwareData {
+	data := DaemonHardwareData{}
+
+	// CPU Usage - use 100ms interval instead of 1 second
+	cpuPercent, err := cpu.Percent(100*time.Millisecond, false)
+	if err == nil && len(cpuPercent) > 0 {
+		data.CpuUsage = int(cpuPercent[0])
+	}
+
+	// Temperature sensors
+	temps, err := host.SensorsTemperatures()
+	if err == nil {
+		// CPU Temperature
+		for _, temp := range temps {
+			if strings.Contains(strings.ToLower(temp.SensorKey), "cpu") ||
+				strings.Contains(strings.ToLower(temp.SensorKey), "package") ||
+				strings.Contains(strings.ToLower(temp.SensorKey), "core") {
+				data.CpuTemp = int(temp.Temperature)
+				break
+			}
+		}
+
+		// Disk Temperature
+		for _, temp := range temps {
+			if strings.Contains(strings.ToLower(temp.SensorKey), "nvme") ||
+				strings.Contains(strings.ToLower(temp.SensorKey), "sda") ||
+				strings.Contains(strings.ToLower(temp.SensorKey), "disk") {
+				data.DiskTemp = int(temp.Temperature)
+				break
+			}
+		}
+	}
+
+	// Memory Usage
+	vmStat, err := mem.VirtualMemory()
+	if err == nil {
+		data.MemoryUsage = int(vmStat.UsedPercent)
+	}
+
+	// GPU data
+	if gpuData := getNvidiaGPUData(); gpuData != nil {
+		data.GpuUsage = gpuData.Usage
+		data.GpuTemp = gpuData.Temp
+	} else {
+		data.GpuUsage = 0
+		data.GpuTemp = 0
+	}
+
+	return data
+}
+
+func getNvidiaGPUData() *struct{ Usage, Temp int } {
+	// Check if nvidia-smi is available first
+	if _, err := exec.LookPath("nvidia-smi"); err != nil {
+		return nil
+	}
+
+	// Try to execute nvidia-smi to get GPU data with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=utilization.gpu,temperature.gpu", "--format=csv,noheader,nounits")
+	cmd.Env = append(os.Environ(), "HOME=/tmp")
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			logger.Debug("GPU detection timeout")
+		} else {
+			logger.Debug("GPU detection failed", "err", err)
+		}
+		return nil
+	}
+
+	// Parse the output
+	outputStr := strings.TrimSpace(string(output))
+	lines := strings.Split(outputStr, "\n")
+	if len(lines) == 0 {
+		logger.Debug("GPU: no output lines from nvidia-smi")
+		return nil
+	}
+
+	// Get first GPU data
+	parts := strings.Split(lines[0], ", ")
+	if len(parts) != 2 {
+		logger.Debug("GPU: unexpected output format", "output", lines[0])
+		return nil
+	}
+
+	usage, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	temp, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+
+	if err1 != nil || err2 != nil {
+		logger.Debug("GPU: parse error", "usage", err1, "temp", err2)
+		return nil
+	}
+
+	logger.Debug("GPU: detected", "usage", usage, "temp", temp)
+	return &struct{ Usage, Temp int }{Usage: usage, Temp: temp}
+}
+
+// sendDaemonDataToDevice posts the PC monitor payload unless the device
+// already shows exactly this data. It reports whether a request was made.
+func sendDaemonDataToDevice(device DaemonDevice, dispData []string, lcdId int) (bool, error) {
+	payload := DaemonPCMonitorPayload{
+		Command: "Device/UpdatePCParaInfo",
+		ScreenList: []DaemonPCMonitorScreenItem{
+			{
+				LcdId:    lcdId,
+				DispData: dispData,
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return false, err
+	}
+
+	key := fmt.Sprintf("%s/%d", device.DevicePrivateIP, lcdId)
+	now := time.Now()
+	if daemonPayloads.Unchanged(key, jsonData, now) {
+		return false, nil
+	}
+	if err := postDaemonJSON(device, jsonData); err != nil {
+		return true, err
+	}
+	daemonPayloads.Acknowledge(key, jsonData, now)
+	return true, nil
+}
+
+// sendDaemonText shows a one-off text message on the device.
+func sendDaemonText(device DaemonDevice, text, color string) error {
+	if color == "" {
+		color = "#FFFFFF"
+	}
+	payload := DaemonTextPayload{
+		Command:    "Draw/SendHttpText",
+		TextId:     messageTextId,
+		Font:       1,
+		TextWidth:  64,
+		Speed:      100,
+		TextString: text,
+		Color:      color,
+		Align:      1,
+	}
+	return postDaemonCommand(device, payload)
+}
+
+// postDaemonCommand sends any other command. These change what is on the
+// screen, so the next PC monitor payload is always sent in full.
+func postDaemonCommand(device DaemonDevice, payload interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	daemonPayloads.ForgetDevice(device.DevicePrivateIP)
+	return postDaemonJSON(device, jsonData)
+}
+
+// postDaemonJSON posts a request body to the device. Both HTTP errors and a
+// non-zero error_code are returned as errors.
+func postDaemonJSON(device DaemonDevice, jsonData []byte) error {
+	url := daemonEndpoints.DeviceURL(device.DevicePrivateIP)
+	daemonRecorder.Payload(url, jsonData)
+	resp, err := daemonHttpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("POST request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("device returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	// The device answers 200 OK to commands it rejects, with the reason in
+	// error_code
+	var result struct {
+		ErrorCode json.RawMessage `json:"error_code"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("invalid response: %v", err)
+	}
+	if code := string(result.ErrorCode); code != "" && code != "0" {
+		return fmt.Errorf("device returned error_code %s", code)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"divoom-monitor/internal/divoom"
+	"divoom-monitor/internal/fakedevice"
+)
+
+// newTestDevice starts a fake device and points the daemon's endpoints and
+// caches at it for the duration of the test.
+func newTestDevice(t *testing.T) (*fakedevice.Server, DaemonDevice) {
+	t.Helper()
+	s := fakedevice.NewServer()
+	t.Cleanup(s.Close)
+
+	endpoints, payloads := daemonEndpoints, daemonPayloads
+	daemonEndpoints = divoom.Endpoints{DiscoveryURL: s.DiscoveryURL(), DevicePort: s.Port()}
+	daemonPayloads = newPayloadCache(DedupConfig{})
+	t.Cleanup(func() {
+		daemonEndpoints, daemonPayloads = endpoints, payloads
+	})
+
+	info := s.Info()
+	return s, DaemonDevice{
+		DeviceName:      info.DeviceName,
+		DeviceId:        info.DeviceId,
+		DevicePrivateIP: info.DevicePrivateIP,
+		Hardware:        info.Hardware,
+	}
+}
+
+func TestPostDaemonJSONReportsErrorCode(t *testing.T) {
+	s, device := newTestDevice(t)
+
+	if err := postDaemonJSON(device, []byte(`{"Command":"Channel/SetBrightness","Brightness":50}`)); err != nil {
+		t.Fatalf("accepted command: %v", err)
+	}
+	s.SetErrorCode(5)
+	if err := postDaemonJSON(device, []byte(`{"Command":"Channel/SetBrightness","Brightness":50}`)); err == nil {
+		t.Errorf("error_code 5 was reported as success")
+	}
+}
+
+func TestSendDaemonDataDedupsOnlyAcceptedPayloads(t *testing.T) {
+	s, device := newTestDevice(t)
+	data := []string{"10%", "20%", "30 C", "40 C", "50%", "60 C"}
+
+	// A rejected payload is not remembered, so it is sent again
+	s.SetErrorCode(5)
+	for i := 0; i < 2; i++ {
+		sent, err := sendDaemonDataToDevice(device, data, 0)
+		if !sent || err == nil {
+			t.Fatalf("send %d with error_code 5: sent=%v err=%v", i, sent, err)
+		}
+	}
+
+	s.SetErrorCode(0)
+	if sent, err := sendDaemonDataToDevice(device, data, 0); !sent || err != nil {
+		t.Fatalf("send after recovery: sent=%v err=%v", sent, err)
+	}
+	if sent, err := sendDaemonDataToDevice(device, data, 0); sent || err != nil {
+		t.Errorf("unchanged payload: sent=%v err=%v, want skipped", sent, err)
+	}
+	if got := len(s.CommandsNamed("Device/UpdatePCParaInfo")); got != 3 {
+		t.Errorf("device received %d payloads, want 3", got)
+	}
+}
+
+func TestBreakerOpensOnErrorCode(t *testing.T) {
+	s, device := newTestDevice(t)
+	breakers := newDeviceBreakers(BackoffConfig{FailureThreshold: 2})
+	breaker := breakers.For(device.DevicePrivateIP)
+	data := []string{"10%", "20%", "30 C", "40 C", "50%", "60 C"}
+
+	s.SetErrorCode(5)
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if !breaker.Allow(now) {
+			t.Fatalf("breaker open after %d failures", i)
+		}
+		if _, err := sendDaemonDataToDevice(device, data, 0); err != nil {
+			breaker.Failure(now, err)
+		} else {
+			breaker.Success(now)
+		}
+	}
+	if breaker.Allow(now) {
+		t.Errorf("breaker still closed after the device rejected two payloads")
+	}
+}
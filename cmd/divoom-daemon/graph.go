@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+	"time"
+
+	"divoom-monitor/internal/pixel"
+)
+
+// GraphConfig describes the frame drawn in graph mode. Panels are stacked
+// top to bottom and share the frame height equally.
+type GraphConfig struct {
+	Size   int          `json:"Size"`   // frame size: 16, 32 or 64, default the device's
+	Speed  int          `json:"Speed"`  // PicSpeed in milliseconds
+	Panels []GraphPanel `json:"Panels"` // default: CPU, GPU and memory usage
+}
+
+// GraphPanel is one metric in the graph frame.
+type GraphPanel struct {
+	Metric string  `json:"Metric"`
+	Style  string  `json:"Style"` // sparkline (history) or bar (current value)
+	Color  string  `json:"Color"`
+	Max    float64 `json:"Max"` // value drawn at full height, default 100
+
+	color color.RGBA
+}
+
+var defaultGraphPanels = []GraphPanel{
+	{Metric: "CpuUsage", Color: "#00FF00"},
+	{Metric: "GpuUsage", Color: "#00FFFF"},
+	{Metric: "MemoryUsage", Color: "#FFFF00"},
+}
+
+// validate checks the graph and fills in defaults. A Size of 0 is left for
+// pagesForDevice to fill in and checked as the largest frame until then.
+func (c *GraphConfig) validate() error {
+	size := c.Size
+	if size == 0 {
+		size = 64
+	}
+	if !pixel.ValidSize(size) {
+		return fmt.Errorf("Size must be one of %v", pixel.Sizes)
+	}
+	if c.Speed <= 0 {
+		c.Speed = 1000
+	}
+	if len(c.Panels) == 0 {
+		c.Panels = append([]GraphPanel(nil), defaultGraphPanels...)
+	}
+	if len(c.Panels) > size/4 {
+		return fmt.Errorf("%d panels do not fit in %d pixels", len(c.Panels), size)
+	}
+	for i := range c.Panels {
+		if err := c.Panels[i].validate(); err != nil {
+			return fmt.Errorf("panel %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (p *GraphPanel) validate() error {
+	if !isMetricName(p.Metric) {
+		return fmt.Errorf("unknown metric %q", p.Metric)
+	}
+	switch p.Style {
+	case "":
+		p.Style = "sparkline"
+	case "sparkline", "bar":
+	default:
+		return fmt.Errorf("unknown style %q", p.Style)
+	}
+	if p.Color == "" {
+		p.Color = "#FFFFFF"
+	}
+	c, err := pixel.ParseColor(p.Color)
+	if err != nil {
+		return err
+	}
+	p.color = c
+	if p.Max == 0 {
+		p.Max = 100
+	}
+	if p.Max < 0 {
+		return fmt.Errorf("Max must be positive")
+	}
+	return nil
+}
+
+// GraphRenderer keeps one column of history per pixel and draws the frame.
+type GraphRenderer struct {
+	config  GraphConfig
+	history [][]float64
+}
+
+func newGraphRenderer(config GraphConfig) *GraphRenderer {
+	return &GraphRenderer{config: config, history: make([][]float64, len(config.Panels))}
+}
+
+// Add appends one sample to the history. It is called every tick, also
+// while nothing is sent, so the graphs have no gaps.
+func (g *GraphRenderer) Add(values map[string]float64) {
+	for i, panel := range g.config.Panels {
+		history := append(g.history[i], values[panel.Metric])
+		if len(history) > g.config.Size {
+			history = history[len(history)-g.config.Size:]
+		}
+		g.history[i] = history
+	}
+}
+
+// Render draws the frame from the history.
+func (g *GraphRenderer) Render() *image.RGBA {
+	size := g.config.Size
+	img := pixel.NewFrame(size)
+	band := size / len(g.config.Panels)
+	for i, panel := range g.config.Panels {
+		history := g.history[i]
+		value := 0.0
+		if len(history) > 0 {
+			value = history[len(history)-1]
+		}
+
+		// Leave a one pixel gap above every panel
+		r := image.Rect(0, i*band+1, size, (i+1)*band)
+		if panel.Style == "bar" {
+			pixel.Bar(img, r, value, panel.Max, panel.color)
+		} else {
+			pixel.Sparkline(img, r, history, panel.Max, panel.color)
+		}
+	}
+	return img
+}
+
+// DaemonGifPayload is a single-frame Draw/SendHttpGif.
+type DaemonGifPayload struct {
+	Command   string `json:"Command"`
+	PicNum    int    `json:"PicNum"`
+	PicWidth  int    `json:"PicWidth"`
+	PicOffset int    `json:"PicOffset"`
+	PicID     int    `json:"PicID"`
+	PicSpeed  int    `json:"PicSpeed"`
+	PicData   string `json:"PicData"`
+}
+
+// maxPicId is where the picture id counter is reset. The device only shows
+// a picture whose id is higher than the last one and slows down when the
+// ids grow large.
+const maxPicId = 1000
+
+// picIds tracks the next Draw/SendHttpGif picture id per device.
+var picIds = struct {
+	sync.Mutex
+	next map[string]int
+}{next: make(map[string]int)}
+
+// sendDaemonFrame uploads img unless the device already shows it.
+func sendDaemonFrame(device DaemonDevice, img *image.RGBA, speed int) (bool, error) {
+	picData := pixel.PicData(img)
+	key := device.DevicePrivateIP + "/gif"
+	now := time.Now()
+	if daemonPayloads.Unchanged(key, []byte(picData), now) {
+		return false, nil
+	}
+
+	picIds.Lock()
+	id := picIds.next[device.DevicePrivateIP]
+	if id == 0 || id > maxPicId {
+		id = 1
+	}
+	picIds.next[device.DevicePrivateIP] = id + 1
+	picIds.Unlock()
+
+	if id == 1 {
+		reset, _ := json.Marshal(DaemonCommandPayload{Command: "Draw/ResetHttpGifId"})
+		if err := postDaemonJSON(device, reset); err != nil {
+			picIds.Lock()
+			delete(picIds.next, device.DevicePrivateIP)
+			picIds.Unlock()
+			return true, err
+		}
+	}
+
+	jsonData, err := json.Marshal(DaemonGifPayload{
+		Command:   "Draw/SendHttpGif",
+		PicNum:    1,
+		PicWidth:  img.Bounds().Dx(),
+		PicOffset: 0,
+		PicID:     id,
+		PicSpeed:  speed,
+		PicData:   picData,
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := postDaemonJSON(device, jsonData); err != nil {
+		return true, err
+	}
+	daemonPayloads.Acknowledge(key, []byte(picData), now)
+	return true, nil
+}
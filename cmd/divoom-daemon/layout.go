@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// defaultLayout reproduces the Windows tool's DispData order:
+// [CpuUse, GpuUse, CpuTemp, GpuTemp, MemUse, DiskTemp]
+var defaultLayout = []string{
+	"{CpuUsage}%",
+	"{GpuUsage}%",
+	"{CpuTemp}°C",
+	"{GpuTemp}°C",
+	"{MemoryUsage}%",
+	"{DiskTemp}°C",
+}
+
+// pcMonitorSlots is the number of DispData entries the PC monitor face shows.
+const pcMonitorSlots = 6
+
+func validateLayout(layout []string) error {
+	if len(layout) > pcMonitorSlots {
+		return fmt.Errorf("layout has %d entries, the PC monitor face shows %d", len(layout), pcMonitorSlots)
+	}
+	for _, tmpl := range layout {
+		if err := validateMetricTemplate(tmpl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateMetricTemplate checks that every {Name} in tmpl is a known metric.
+func validateMetricTemplate(tmpl string) error {
+	for rest := tmpl; ; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			return nil
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return fmt.Errorf("unterminated placeholder in %q", tmpl)
+		}
+		if name := rest[start+1 : start+end]; !isMetricName(name) {
+			return fmt.Errorf("unknown metric {%s} in %q", name, tmpl)
+		}
+		rest = rest[start+end+1:]
+	}
+}
+
+// expandMetricTemplate replaces {Name} placeholders with rounded values. A
+// '}' without a '{' before it is kept as it is.
+func expandMetricTemplate(tmpl string, values map[string]float64) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			b.WriteString(tmpl)
+			return b.String()
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			b.WriteString(tmpl)
+			return b.String()
+		}
+		end += start
+		b.WriteString(tmpl[:start])
+		name := tmpl[start+1 : end]
+		if value, ok := values[name]; ok {
+			b.WriteString(strconv.Itoa(int(math.Round(value))))
+		} else {
+			b.WriteString(tmpl[start : end+1])
+		}
+		tmpl = tmpl[end+1:]
+	}
+}
+
+// renderLayout builds the DispData strings for one update.
+func renderLayout(layout []string, values map[string]float64) []string {
+	if len(layout) == 0 {
+		layout = defaultLayout
+	}
+	dispData := make([]string, pcMonitorSlots)
+	for i, tmpl := range layout {
+		dispData[i] = expandMetricTemplate(tmpl, values)
+	}
+	return dispData
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestExpandMetricTemplate(t *testing.T) {
+	values := map[string]float64{"CpuUsage": 12.6, "CpuTempMax": 80}
+	tests := map[string]string{
+		"{CpuUsage}%":             "13%",
+		"a}{CpuUsage}":            "a}13",
+		"}{CpuUsage}}":            "}13}",
+		"{CpuTempMax}C {Unknown}": "80C {Unknown}",
+		"open {CpuUsage":          "open {CpuUsage",
+		"":                        "",
+	}
+	for tmpl, want := range tests {
+		if got := expandMetricTemplate(tmpl, values); got != want {
+			t.Errorf("expandMetricTemplate(%q) = %q, want %q", tmpl, got, want)
+		}
+	}
+}
+
+func TestValidateMetricTemplate(t *testing.T) {
+	tests := map[string]bool{
+		"{CpuUsage}%":     true,
+		"a}{CpuUsage}":    true,
+		"{GpuTempAvg}C":   true,
+		"{Unknown}":       false,
+		"{CpuUsage":       false,
+		"{a{CpuUsage}":    false,
+		"no placeholders": true,
+	}
+	for tmpl, valid := range tests {
+		if err := validateMetricTemplate(tmpl); (err == nil) != valid {
+			t.Errorf("validateMetricTemplate(%q) = %v, want valid=%v", tmpl, err, valid)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"log/syslog"
+	"os"
+	"sync"
+)
+
+// logger is replaced by setupLogging; the default is used by tests.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// fatal logs at error level and exits.
+func fatal(msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// setupLogging configures logger for syslog, a log file or stderr. level is
+// debug, info, warn or error; format is text or json. On stderr under
+// systemd (JOURNAL_STREAM is set) and on syslog each line carries its
+// priority and no timestamp, as the journal adds its own. The returned log
+// file, nil unless logFile is set, can be reopened after rotation.
+func setupLogging(useSyslog bool, logFile, level, format string) (*LogFile, error) {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q (debug, info, warn or error)", level)
+	}
+	if format != "text" && format != "json" {
+		return nil, fmt.Errorf("invalid log format %q (text or json)", format)
+	}
+
+	options := &slog.HandlerOptions{Level: minLevel}
+	newHandler := func(w io.Writer) slog.Handler {
+		if format == "json" {
+			return slog.NewJSONHandler(w, options)
+		}
+		return slog.NewTextHandler(w, options)
+	}
+	// withPriority is for outputs that take a priority per line and add
+	// their own timestamps
+	withPriority := func(write func(level slog.Level, p []byte) error) slog.Handler {
+		options.ReplaceAttr = dropTime
+		w := &priorityWriter{write: write}
+		return &priorityHandler{Handler: newHandler(w), out: w}
+	}
+
+	switch {
+	case useSyslog:
+		syslogger, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "divoom-daemon")
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to syslog: %v", err)
+		}
+		logger = slog.New(withPriority(func(level slog.Level, p []byte) error {
+			switch {
+			case level >= slog.LevelError:
+				return syslogger.Err(string(p))
+			case level >= slog.LevelWarn:
+				return syslogger.Warning(string(p))
+			case level >= slog.LevelInfo:
+				return syslogger.Info(string(p))
+			default:
+				return syslogger.Debug(string(p))
+			}
+		}))
+		return nil, nil
+	case logFile != "":
+		file, err := openLogFile(logFile)
+		if err != nil {
+			return nil, err
+		}
+		logger = slog.New(newHandler(file))
+		return file, nil
+	case os.Getenv("JOURNAL_STREAM") != "":
+		logger = slog.New(withPriority(func(level slog.Level, p []byte) error {
+			_, err := fmt.Fprintf(os.Stderr, "<%d>%s", journalPriority(level), p)
+			return err
+		}))
+		return nil, nil
+	default:
+		logger = slog.New(newHandler(os.Stderr))
+		return nil, nil
+	}
+}
+
+func dropTime(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey && len(groups) == 0 {
+		return slog.Attr{}
+	}
+	return a
+}
+
+// journalPriority maps a level to the syslog priority the journal reads
+// from a <N> line prefix.
+func journalPriority(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 4
+	case level >= slog.LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// priorityWriter passes each line to write together with the level of the
+// record being written, which priorityHandler sets.
+type priorityWriter struct {
+	mu    sync.Mutex
+	level slog.Level
+	write func(level slog.Level, p []byte) error
+}
+
+func (w *priorityWriter) Write(p []byte) (int, error) {
+	if err := w.write(w.level, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type priorityHandler struct {
+	slog.Handler
+	out *priorityWriter
+}
+
+func (h *priorityHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.out.mu.Lock()
+	defer h.out.mu.Unlock()
+	h.out.level = r.Level
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *priorityHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &priorityHandler{Handler: h.Handler.WithAttrs(attrs), out: h.out}
+}
+
+func (h *priorityHandler) WithGroup(name string) slog.Handler {
+	return &priorityHandler{Handler: h.Handler.WithGroup(name), out: h.out}
+}
+
+// LogFile is a log file that can be reopened, e.g. by SIGUSR1 after
+// logrotate has moved it.
+type LogFile struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+func openLogFile(path string) (*LogFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %v", err)
+	}
+	return &LogFile{path: path, file: file}, nil
+}
+
+func (f *LogFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Write(p)
+}
+
+// Reopen opens the path again and closes the previous file.
+func (f *LogFile) Reopen() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to reopen log file: %v", err)
+	}
+	f.mu.Lock()
+	old := f.file
+	f.file = file
+	f.mu.Unlock()
+	return old.Close()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPriorityHandlerPrefixesLevel(t *testing.T) {
+	var out strings.Builder
+	w := &priorityWriter{write: func(level slog.Level, p []byte) error {
+		fmt.Fprintf(&out, "<%d>%s", journalPriority(level), p)
+		return nil
+	}}
+	options := &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: dropTime}
+	log := slog.New(&priorityHandler{Handler: slog.NewTextHandler(w, options), out: w})
+
+	log.Debug("Sent", "device", "192.0.2.1")
+	log.Warn("Error sending data", "err", "timeout")
+	want := "<7>level=DEBUG msg=Sent device=192.0.2.1\n" +
+		"<4>level=WARN msg=\"Error sending data\" err=timeout\n"
+	if out.String() != want {
+		t.Errorf("output\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestLogFileReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	file, err := openLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(file, "before")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(file, "after")
+
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if string(rotated) != "before\n" || string(current) != "after\n" {
+		t.Errorf("rotated %q, current %q", rotated, current)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DaemonMetrics keeps the latest hardware sample and send statistics so they
+// can be scraped in Prometheus text format.
+type DaemonMetrics struct {
+	mu           sync.Mutex
+	data         DaemonHardwareData
+	haveData     bool
+	deviceIP     string
+	lcdId        int
+	deviceUp     bool
+	sends        map[string]*sendCounts // by device IP
+	lastLatency  time.Duration
+	lastSendTime time.Time
+	startTime    time.Time
+}
+
+// sendCounts are the send results of one device. Counters are kept per
+// device, so switching to another one does not carry the counts over.
+type sendCounts struct {
+	success, failure, skipped uint64
+}
+
+var daemonMetrics = &DaemonMetrics{startTime: time.Now()}
+
+// counts returns the counters of the current device.
+func (m *DaemonMetrics) counts() *sendCounts {
+	if m.sends == nil {
+		m.sends = make(map[string]*sendCounts)
+	}
+	c, ok := m.sends[m.deviceIP]
+	if !ok {
+		c = &sendCounts{}
+		m.sends[m.deviceIP] = c
+	}
+	return c
+}
+
+// SetTarget records the device and LCD the daemon is currently sending to.
+func (m *DaemonMetrics) SetTarget(device DaemonDevice, lcdId int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deviceIP = device.DevicePrivateIP
+	m.lcdId = lcdId
+}
+
+// RecordSample stores the most recently collected hardware data.
+func (m *DaemonMetrics) RecordSample(data DaemonHardwareData) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = data
+	m.haveData = true
+}
+
+// RecordSend updates the send counters with the outcome of one POST.
+func (m *DaemonMetrics) RecordSend(latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastLatency = latency
+	m.lastSendTime = time.Now()
+	if err != nil {
+		m.counts().failure++
+		m.deviceUp = false
+	} else {
+		m.counts().success++
+		m.deviceUp = true
+	}
+}
+
+// RecordSkip counts a tick whose payload was identical to the last one sent.
+func (m *DaemonMetrics) RecordSkip() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts().skipped++
+}
+
+func (m *DaemonMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.writeExposition(w)
+}
+
+// writeExposition writes all metrics in the Prometheus text exposition format.
+func (m *DaemonMetrics) writeExposition(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	writeMetric(w, "divoom_build_info", "gauge", "Build information of the daemon.",
+		fmt.Sprintf("{version=%q}", version), 1)
+	writeMetric(w, "divoom_start_time_seconds", "gauge", "Start time of the daemon since unix epoch in seconds.",
+		"", float64(m.startTime.Unix()))
+
+	if m.haveData {
+		writeMetric(w, "divoom_cpu_usage_percent", "gauge", "CPU usage in percent.", "", float64(m.data.CpuUsage))
+		writeMetric(w, "divoom_cpu_temperature_celsius", "gauge", "CPU temperature in degrees Celsius.", "", float64(m.data.CpuTemp))
+		writeMetric(w, "divoom_gpu_usage_percent", "gauge", "GPU usage in percent.", "", float64(m.data.GpuUsage))
+		writeMetric(w, "divoom_gpu_temperature_celsius", "gauge", "GPU temperature in degrees Celsius.", "", float64(m.data.GpuTemp))
+		writeMetric(w, "divoom_memory_usage_percent", "gauge", "Memory usage in percent.", "", float64(m.data.MemoryUsage))
+		writeMetric(w, "divoom_disk_temperature_celsius", "gauge", "Disk temperature in degrees Celsius.", "", float64(m.data.DiskTemp))
+	}
+
+	labels := fmt.Sprintf("{device=%q,lcd=\"%d\"}", m.deviceIP, m.lcdId)
+	up := 0.0
+	if m.deviceUp {
+		up = 1
+	}
+	writeMetric(w, "divoom_device_up", "gauge", "Whether the last send to the device succeeded.", labels, up)
+
+	fmt.Fprintln(w, "# HELP divoom_sends_total Number of updates sent to the device (or skipped as unchanged) by result.")
+	fmt.Fprintln(w, "# TYPE divoom_sends_total counter")
+	devices := make([]string, 0, len(m.sends))
+	for ip := range m.sends {
+		devices = append(devices, ip)
+	}
+	sort.Strings(devices)
+	for _, ip := range devices {
+		c := m.sends[ip]
+		fmt.Fprintf(w, "divoom_sends_total{device=%q,result=\"success\"} %d\n", ip, c.success)
+		fmt.Fprintf(w, "divoom_sends_total{device=%q,result=\"failure\"} %d\n", ip, c.failure)
+		fmt.Fprintf(w, "divoom_sends_total{device=%q,result=\"skipped\"} %d\n", ip, c.skipped)
+	}
+
+	if !m.lastSendTime.IsZero() {
+		writeMetric(w, "divoom_last_send_duration_seconds", "gauge", "Duration of the last send to the device.",
+			labels, m.lastLatency.Seconds())
+		writeMetric(w, "divoom_last_send_timestamp_seconds", "gauge", "Time of the last send attempt since unix epoch in seconds.",
+			labels, float64(m.lastSendTime.Unix()))
+	}
+}
+
+func writeMetric(w io.Writer, name, kind, help, labels string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// startMetricsServer listens on addr before returning, so a busy or invalid
+// address is reported at startup.
+func startMetricsServer(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", daemonMetrics)
+
+	go func() {
+		logger.Info("Serving metrics", "url", "http://"+addr+"/metrics")
+		if err := http.Serve(listener, mux); err != nil {
+			logger.Error("Metrics server stopped", "err", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsExposition(t *testing.T) {
+	m := &DaemonMetrics{startTime: time.Unix(1700000000, 0)}
+	var out strings.Builder
+	m.writeExposition(&out)
+	if strings.Contains(out.String(), "divoom_cpu_usage_percent") || strings.Contains(out.String(), "divoom_sends_total{") {
+		t.Errorf("hardware or send metrics before the first sample:\n%s", out.String())
+	}
+
+	m.SetTarget(DaemonDevice{DevicePrivateIP: "192.168.1.50"}, 1)
+	m.RecordSample(DaemonHardwareData{CpuUsage: 42, GpuTemp: 65})
+	m.RecordSend(250*time.Millisecond, nil)
+	m.RecordSend(time.Second, errors.New("timeout"))
+	m.RecordSkip()
+
+	out.Reset()
+	m.writeExposition(&out)
+	for _, line := range []string{
+		"# TYPE divoom_build_info gauge",
+		"divoom_start_time_seconds 1700000000",
+		"divoom_cpu_usage_percent 42",
+		"divoom_gpu_temperature_celsius 65",
+		`divoom_device_up{device="192.168.1.50",lcd="1"} 0`,
+		"# TYPE divoom_sends_total counter",
+		`divoom_sends_total{device="192.168.1.50",result="success"} 1`,
+		`divoom_sends_total{device="192.168.1.50",result="failure"} 1`,
+		`divoom_sends_total{device="192.168.1.50",result="skipped"} 1`,
+		`divoom_last_send_duration_seconds{device="192.168.1.50",lcd="1"} 1`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestMetricsCountPerDevice(t *testing.T) {
+	m := &DaemonMetrics{}
+	m.SetTarget(DaemonDevice{DevicePrivateIP: "192.168.1.50"}, 0)
+	m.RecordSend(0, nil)
+	m.RecordSend(0, nil)
+	m.SetTarget(DaemonDevice{DevicePrivateIP: "192.168.1.51"}, 0)
+	m.RecordSend(0, nil)
+
+	var out strings.Builder
+	m.writeExposition(&out)
+	for _, line := range []string{
+		`divoom_sends_total{device="192.168.1.50",result="success"} 2`,
+		`divoom_sends_total{device="192.168.1.51",result="success"} 1`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestMetricsServerReportsBusyAddress(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	if err := startMetricsServer(busy.Addr().String()); err == nil {
+		t.Errorf("metrics server started on a busy address")
+	}
+	if err := startMetricsServer("127.0.0.1:notaport"); err == nil {
+		t.Errorf("metrics server started on an invalid address")
+	}
+}
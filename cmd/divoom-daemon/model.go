@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+
+	"divoom-monitor/internal/divoom"
+	"divoom-monitor/internal/pixel"
+)
+
+// modeCapabilities are the commands each display mode sends.
+var modeCapabilities = map[string]divoom.Capability{
+	modePCMonitor: divoom.CapPCMonitor,
+	modeText:      divoom.CapText,
+	modeGraph:     divoom.CapGif,
+	modeScreen:    divoom.CapGif,
+}
+
+func deviceModel(device DaemonDevice) (divoom.Model, bool) {
+	return divoom.LookupModel(device.Hardware, device.DeviceName)
+}
+
+// checkLcd validates the LCD ID against the device model. Devices of an
+// unknown model, e.g. given by IP address only, accept 0-4.
+func checkLcd(device DaemonDevice, lcdId int) error {
+	if model, ok := deviceModel(device); ok {
+		return model.CheckLCD(lcdId)
+	}
+	if lcdId < 0 || lcdId > 4 {
+		return fmt.Errorf("LCD ID must be between 0 and 4")
+	}
+	return nil
+}
+
+// checkPages logs a warning for every page the device model cannot show.
+// The pages are sent anyway, as the profile may be out of date.
+func checkPages(device DaemonDevice, pages []PageConfig) {
+	model, ok := deviceModel(device)
+	if !ok {
+		return
+	}
+	for _, page := range pages {
+		if !model.Supports(modeCapabilities[page.Mode]) {
+			logger.Warn("Page uses a mode the device does not support", "page", page.Name, "mode", page.Mode, "model", model.Name)
+		}
+	}
+}
+
+// defaultMode is the display mode of pages without a Mode: the PC monitor
+// clock face where the model has one, else the graph on models that take
+// frames, else text lines. Devices of an unknown model get the clock face.
+func defaultMode(device DaemonDevice) string {
+	model, ok := deviceModel(device)
+	switch {
+	case !ok || model.Supports(divoom.CapPCMonitor):
+		return modePCMonitor
+	case model.Supports(divoom.CapGif):
+		return modeGraph
+	case model.Supports(divoom.CapText):
+		return modeText
+	default:
+		return modePCMonitor
+	}
+}
+
+// frameSize is the size of Draw frames for the device, 64 where the model
+// is unknown or takes no frames.
+func frameSize(device DaemonDevice) int {
+	if model, ok := deviceModel(device); ok && pixel.ValidSize(model.Size) {
+		return model.Size
+	}
+	return 64
+}
+
+// pagesForDevice returns the pages with what the configuration leaves open,
+// the display mode and the frame size, taken from the device model. A
+// frame size that differs from the display of a known model is an error,
+// as the device does not scale frames.
+func pagesForDevice(device DaemonDevice, configs []PageConfig) ([]PageConfig, error) {
+	mode, size := defaultMode(device), frameSize(device)
+	model, ok := deviceModel(device)
+	fixedSize := ok && model.Supports(divoom.CapGif)
+	pages := make([]PageConfig, len(configs))
+	for i, page := range configs {
+		if page.Mode == "" {
+			page.Mode = mode
+		}
+		configured := 0
+		switch page.Mode {
+		case modeGraph:
+			configured = page.Graph.Size
+		case modeScreen:
+			configured = page.Screen.Size
+		}
+		if fixedSize && configured != 0 && configured != model.Size {
+			return nil, fmt.Errorf("page %s: frame size %d does not match the %dx%d display of the %s",
+				page.Name, configured, model.Size, model.Size, model.Name)
+		}
+		if page.Graph.Size == 0 {
+			page.Graph.Size = size
+		}
+		if page.Screen.Size == 0 {
+			page.Screen.Size = size
+		}
+		if err := page.Graph.validate(); err != nil {
+			return nil, fmt.Errorf("page %s: graph: %v", page.Name, err)
+		}
+		if err := validateScreen(&page.Screen); err != nil {
+			return nil, fmt.Errorf("page %s: screen: %v", page.Name, err)
+		}
+		if page.Mode == modeScreen && len(page.Screen.Widgets) == 0 && page.Screen.Size != defaultScreen.Size {
+			return nil, fmt.Errorf("page %s: the default screen is %dx%d, add Widgets for a %dx%d frame",
+				page.Name, defaultScreen.Size, defaultScreen.Size, page.Screen.Size, page.Screen.Size)
+		}
+		pages[i] = page
+	}
+	checkPages(device, pages)
+	return pages, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"divoom-monitor/internal/divoom"
+)
+
+func TestPagesForDevice(t *testing.T) {
+	config, err := loadDaemonConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		device DaemonDevice
+		mode   string
+		size   int
+	}{
+		{DaemonDevice{DeviceName: "Desk", Hardware: divoom.HardwareTimeGate}, modePCMonitor, 64},
+		{DaemonDevice{DeviceName: "Pixoo64"}, modeGraph, 64},
+		{DaemonDevice{DeviceName: "Pixoo Max"}, modeGraph, 32},
+		{DaemonDevice{DeviceName: "Pixoo16"}, modeGraph, 16},
+		{DaemonDevice{DevicePrivateIP: "192.168.1.50"}, modePCMonitor, 64}, // unknown model
+	}
+	for _, tt := range tests {
+		pages, err := pagesForDevice(tt.device, config.pages())
+		if err != nil {
+			t.Errorf("%s: %v", divoom.ModelName(tt.device.Hardware, tt.device.DeviceName), err)
+			continue
+		}
+		page := pages[0]
+		if page.Mode != tt.mode || page.Graph.Size != tt.size || page.Screen.Size != tt.size {
+			t.Errorf("%s: mode %s, graph %d, screen %d; want %s, %d",
+				divoom.ModelName(tt.device.Hardware, tt.device.DeviceName),
+				page.Mode, page.Graph.Size, page.Screen.Size, tt.mode, tt.size)
+		}
+	}
+}
+
+func TestPagesForDeviceKeepsConfiguredMode(t *testing.T) {
+	pages := []PageConfig{{Name: "lines", DisplayConfig: DisplayConfig{Mode: modeText}}}
+	if err := validatePages(pages); err != nil {
+		t.Fatal(err)
+	}
+	got, err := pagesForDevice(DaemonDevice{DeviceName: "Pixoo64"}, pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Mode != modeText {
+		t.Errorf("mode %s, want %s", got[0].Mode, modeText)
+	}
+	if pages[0].Graph.Size != 0 {
+		t.Errorf("the configuration was changed: graph size %d", pages[0].Graph.Size)
+	}
+}
+
+func TestPagesForDeviceRejectsOtherFrameSizes(t *testing.T) {
+	graph := []PageConfig{{Name: "history", DisplayConfig: DisplayConfig{Mode: modeGraph, Graph: GraphConfig{Size: 64}}}}
+	if err := validatePages(graph); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pagesForDevice(DaemonDevice{DeviceName: "Pixoo64"}, graph); err != nil {
+		t.Errorf("64x64 graph on a Pixoo 64: %v", err)
+	}
+	if _, err := pagesForDevice(DaemonDevice{DeviceName: "Pixoo Max"}, graph); err == nil {
+		t.Errorf("64x64 graph accepted for a Pixoo Max")
+	}
+	// Unknown models take any size
+	if _, err := pagesForDevice(DaemonDevice{DevicePrivateIP: "192.168.1.50"}, graph); err != nil {
+		t.Errorf("64x64 graph on an unknown device: %v", err)
+	}
+
+	screen := []PageConfig{{Name: "dashboard", DisplayConfig: DisplayConfig{Mode: modeScreen}}}
+	if err := validatePages(screen); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pagesForDevice(DaemonDevice{DeviceName: "Pixoo16"}, screen); err == nil {
+		t.Errorf("the 64x64 default screen was accepted for a Pixoo 16")
+	}
+}
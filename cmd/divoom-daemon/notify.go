@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// NotifierConfig describes one destination for alert transitions.
+type NotifierConfig struct {
+	Type        string            `json:"Type"` // webhook, command or desktop
+	URL         string            `json:"URL"`
+	Headers     map[string]string `json:"Headers"`
+	Command     string            `json:"Command"`
+	Args        []string          `json:"Args"`
+	MinInterval Duration          `json:"MinInterval"`
+}
+
+func (c *NotifierConfig) validate() error {
+	switch c.Type {
+	case "webhook":
+		if c.URL == "" {
+			return fmt.Errorf("webhook notifier needs a URL")
+		}
+	case "command":
+		if c.Command == "" {
+			return fmt.Errorf("command notifier needs a Command")
+		}
+	case "desktop":
+	default:
+		return fmt.Errorf("unknown notifier type %q", c.Type)
+	}
+	if c.MinInterval.Duration < 0 {
+		return fmt.Errorf("MinInterval must not be negative")
+	}
+	return nil
+}
+
+// AlertEvent is the JSON body posted to webhooks. Command hooks get the
+// same fields as DIVOOM_ALERT_* environment variables.
+type AlertEvent struct {
+	Alert     string    `json:"Alert"`
+	State     string    `json:"State"` // firing or cleared
+	Metric    string    `json:"Metric"`
+	Value     float64   `json:"Value"`
+	Op        string    `json:"Op"`
+	Threshold float64   `json:"Threshold"`
+	Host      string    `json:"Host"`
+	Time      time.Time `json:"Time"`
+	Message   string    `json:"Message"`
+}
+
+func newAlertEvent(t AlertTransition, now time.Time) AlertEvent {
+	state := "cleared"
+	if t.Firing {
+		state = "firing"
+	}
+	host, _ := os.Hostname()
+	return AlertEvent{
+		Alert:     t.Rule.Name,
+		State:     state,
+		Metric:    t.Rule.Metric,
+		Value:     t.Value,
+		Op:        t.Rule.Op,
+		Threshold: t.Rule.Threshold,
+		Host:      host,
+		Time:      now,
+		Message:   t.String(),
+	}
+}
+
+type NotifySink interface {
+	Send(event AlertEvent) error
+}
+
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (s *webhookSink) Send(event AlertEvent) error {
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook POST failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+type commandSink struct {
+	command string
+	args    []string
+}
+
+func (s *commandSink) Send(event AlertEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, s.command, s.args...)
+	cmd.Env = append(os.Environ(),
+		"DIVOOM_ALERT_NAME="+event.Alert,
+		"DIVOOM_ALERT_STATE="+event.State,
+		"DIVOOM_ALERT_METRIC="+event.Metric,
+		"DIVOOM_ALERT_VALUE="+formatAlertValue(event.Value),
+		"DIVOOM_ALERT_THRESHOLD="+formatAlertValue(event.Threshold),
+		"DIVOOM_ALERT_MESSAGE="+event.Message,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("command %s failed: %v: %s", s.command, err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// desktopSink shows a freedesktop notification through notify-send.
+type desktopSink struct{}
+
+func (s *desktopSink) Send(event AlertEvent) error {
+	urgency := "normal"
+	if event.State == "firing" {
+		urgency = "critical"
+	}
+	summary := fmt.Sprintf("Divoom alert %s: %s", event.State, event.Alert)
+	body := fmt.Sprintf("%s=%s (%s %s)", event.Metric, formatAlertValue(event.Value),
+		event.Op, formatAlertValue(event.Threshold))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "notify-send", "--app-name=divoom-daemon", "--urgency="+urgency, summary, body)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify-send failed: %v: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// rateLimitedSink delivers at most one firing notification per alert every
+// minInterval. A cleared notification is only delivered if the firing one
+// it belongs to was.
+type rateLimitedSink struct {
+	name        string
+	sink        NotifySink
+	minInterval time.Duration
+
+	mu        sync.Mutex
+	lastFired map[string]time.Time
+	delivered map[string]bool
+}
+
+func (s *rateLimitedSink) allow(event AlertEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event.State != "firing" {
+		allowed := s.delivered[event.Alert]
+		s.delivered[event.Alert] = false
+		return allowed
+	}
+
+	if last, ok := s.lastFired[event.Alert]; ok && event.Time.Sub(last) < s.minInterval {
+		s.delivered[event.Alert] = false
+		return false
+	}
+	s.lastFired[event.Alert] = event.Time
+	s.delivered[event.Alert] = true
+	return true
+}
+
+// Notifier fans alert transitions out to the configured sinks without
+// blocking the monitoring loop.
+type Notifier struct {
+	sinks []*rateLimitedSink
+}
+
+func newNotifier(configs []NotifierConfig) *Notifier {
+	n := &Notifier{}
+	for _, c := range configs {
+		var sink NotifySink
+		name := c.Type
+		switch c.Type {
+		case "webhook":
+			sink = &webhookSink{url: c.URL, headers: c.Headers, client: &http.Client{Timeout: 10 * time.Second}}
+			name = "webhook " + c.URL
+		case "command":
+			sink = &commandSink{command: c.Command, args: c.Args}
+			name = "command " + c.Command
+		case "desktop":
+			sink = &desktopSink{}
+		}
+		n.sinks = append(n.sinks, &rateLimitedSink{
+			name:        name,
+			sink:        sink,
+			minInterval: c.MinInterval.Duration,
+			lastFired:   make(map[string]time.Time),
+			delivered:   make(map[string]bool),
+		})
+	}
+	return n
+}
+
+func (n *Notifier) Notify(t AlertTransition) {
+	event := newAlertEvent(t, time.Now())
+	for _, s := range n.sinks {
+		if !s.allow(event) {
+			logger.Info("Notification suppressed by rate limit", "sink", s.name, "alert", event.Alert)
+			continue
+		}
+		go func(s *rateLimitedSink) {
+			if err := s.sink.Send(event); err != nil {
+				logger.Warn("Notification failed", "sink", s.name, "err", err)
+			}
+		}(s)
+	}
+}
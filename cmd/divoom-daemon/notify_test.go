@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookSinkPostsEvent(t *testing.T) {
+	received := make(chan AlertEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+		}
+		var event AlertEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	sink := &webhookSink{
+		url:     server.URL,
+		headers: map[string]string{"Authorization": "Bearer secret"},
+		client:  server.Client(),
+	}
+	rule := AlertRule{Name: "CPU hot", Metric: "CpuTemp", Op: ">", Threshold: 90}
+	event := newAlertEvent(AlertTransition{Rule: rule, Firing: true, Value: 93}, time.Now())
+
+	if err := sink.Send(event); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	got := <-received
+	if got.Alert != "CPU hot" || got.State != "firing" || got.Metric != "CpuTemp" || got.Value != 93 || got.Threshold != 90 {
+		t.Errorf("unexpected event: %+v", got)
+	}
+}
+
+func TestWebhookSinkReportsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sink := &webhookSink{url: server.URL, client: server.Client()}
+	if err := sink.Send(AlertEvent{Alert: "x", State: "firing"}); err == nil {
+		t.Fatal("Send succeeded, want error for status 500")
+	}
+}
+
+func TestRateLimitedSink(t *testing.T) {
+	s := &rateLimitedSink{
+		minInterval: 5 * time.Minute,
+		lastFired:   make(map[string]time.Time),
+		delivered:   make(map[string]bool),
+	}
+	start := time.Now()
+	event := func(state string, offset time.Duration) AlertEvent {
+		return AlertEvent{Alert: "CPU hot", State: state, Time: start.Add(offset)}
+	}
+
+	steps := []struct {
+		event AlertEvent
+		want  bool
+	}{
+		{event("firing", 0), true},
+		{event("cleared", time.Minute), true},
+		{event("firing", 2*time.Minute), false},  // within MinInterval
+		{event("cleared", 3*time.Minute), false}, // its firing was suppressed
+		{event("firing", 6*time.Minute), true},   // interval elapsed
+		{event("cleared", 7*time.Minute), true},
+	}
+	for i, step := range steps {
+		if got := s.allow(step.event); got != step.want {
+			t.Errorf("step %d (%s): allow = %v, want %v", i, step.event.State, got, step.want)
+		}
+	}
+}
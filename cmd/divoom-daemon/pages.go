@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"divoom-monitor/internal/divoom"
+	"divoom-monitor/internal/pixel"
+)
+
+// PageConfig is one page of the carousel: a display mode with its settings
+// and how long it is shown.
+type PageConfig struct {
+	Name     string   `json:"Name"`
+	Duration Duration `json:"Duration"` // default 15s
+	DisplayConfig
+}
+
+func validatePages(pages []PageConfig) error {
+	names := make(map[string]bool)
+	for i := range pages {
+		page := &pages[i]
+		if page.Name == "" {
+			return fmt.Errorf("page %d: missing Name", i+1)
+		}
+		if names[page.Name] {
+			return fmt.Errorf("page %d: duplicate name %q", i+1, page.Name)
+		}
+		names[page.Name] = true
+		if page.Duration.Duration <= 0 {
+			page.Duration.Duration = 15 * time.Second
+		}
+		if err := page.DisplayConfig.validate(); err != nil {
+			return fmt.Errorf("page %s: %v", page.Name, err)
+		}
+	}
+	return nil
+}
+
+func hasPage(pages []PageConfig, name string) bool {
+	for _, page := range pages {
+		if page.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Page is a configured page with the renderers that keep its history.
+type Page struct {
+	PageConfig
+	graph  *GraphRenderer
+	screen *ScreenRenderer
+}
+
+func newPage(config PageConfig) *Page {
+	return &Page{
+		PageConfig: config,
+		graph:      newGraphRenderer(config.Graph),
+		screen:     newScreenRenderer(config.Screen),
+	}
+}
+
+// Send puts the page on the device and reports whether anything was posted.
+func (p *Page) Send(device DaemonDevice, values map[string]float64, lcdId int) (bool, error) {
+	switch p.Mode {
+	case modeText:
+		return sendDaemonTextLines(device, p.Text, values)
+	case modeGraph:
+		return sendDaemonFrame(device, p.graph.Render(), p.Graph.Speed)
+	case modeScreen:
+		return sendDaemonFrame(device, p.screen.Render(), 1000)
+	default:
+		return sendDaemonDataToDevice(device, renderLayout(p.Layout, values), lcdId)
+	}
+}
+
+// Carousel rotates through the pages. A page can be pinned from the API, and
+// an alert with a Page pins that page while it fires.
+type Carousel struct {
+	mu      sync.Mutex
+	pages   []*Page
+	current int
+	since   time.Time
+	pinned  int // -1 when rotating
+	alert   int // page pinned by a firing alert, -1 for none
+}
+
+var daemonPages = &Carousel{pinned: -1, alert: -1}
+
+// Configure replaces the pages. The current and pinned pages are kept if a
+// page with the same name still exists.
+func (c *Carousel) Configure(configs []PageConfig) {
+	pages := make([]*Page, len(configs))
+	for i, config := range configs {
+		pages[i] = newPage(config)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	current, pinned := 0, -1
+	for i, page := range pages {
+		if len(c.pages) > 0 && page.Name == c.pages[c.current].Name {
+			current = i
+		}
+		if c.pinned >= 0 && page.Name == c.pages[c.pinned].Name {
+			pinned = i
+		}
+	}
+	c.pages, c.current, c.pinned, c.alert = pages, current, pinned, -1
+}
+
+// Add records one sample for the history of every page.
+func (c *Carousel) Add(values map[string]float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, page := range c.pages {
+		page.graph.Add(values)
+		page.screen.Add(values)
+	}
+}
+
+// Select returns the page to show at now, advancing the rotation when the
+// current page's time is up. alertPage, if set, takes precedence.
+func (c *Carousel) Select(now time.Time, alertPage string) *Page {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.alert = -1
+	if alertPage != "" {
+		c.alert = c.index(alertPage)
+	}
+	if c.alert >= 0 {
+		return c.pages[c.alert]
+	}
+	if c.pinned >= 0 {
+		return c.pages[c.pinned]
+	}
+	if c.since.IsZero() {
+		c.since = now
+	} else if now.Sub(c.since) >= c.pages[c.current].Duration.Duration {
+		c.current = (c.current + 1) % len(c.pages)
+		c.since = now
+	}
+	return c.pages[c.current]
+}
+
+// Next moves to the following page, or moves the pin there when pinned.
+func (c *Carousel) Next() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pinned >= 0 {
+		c.pinned = (c.pinned + 1) % len(c.pages)
+		return c.pages[c.pinned].Name
+	}
+	// The next Select starts the page's time on the loop's clock
+	c.current = (c.current + 1) % len(c.pages)
+	c.since = time.Time{}
+	return c.pages[c.current].Name
+}
+
+// Pin stops the rotation on the named page; "" resumes the rotation.
+func (c *Carousel) Pin(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if name == "" {
+		if c.pinned >= 0 {
+			c.current, c.since = c.pinned, time.Time{}
+		}
+		c.pinned = -1
+		return nil
+	}
+	i := c.index(name)
+	if i < 0 {
+		return fmt.Errorf("unknown page %q", name)
+	}
+	c.pinned = i
+	return nil
+}
+
+func (c *Carousel) index(name string) int {
+	for i, page := range c.pages {
+		if page.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// CarouselStatus is the JSON document returned by the pages endpoint.
+type CarouselStatus struct {
+	Current string   `json:"Current"`
+	Pinned  string   `json:"Pinned,omitempty"`
+	Alert   string   `json:"Alert,omitempty"` // page pinned by a firing alert
+	Pages   []string `json:"Pages"`
+}
+
+func (c *Carousel) Status() CarouselStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := CarouselStatus{}
+	for _, page := range c.pages {
+		status.Pages = append(status.Pages, page.Name)
+	}
+	if len(c.pages) == 0 {
+		return status
+	}
+	status.Current = c.pages[c.current].Name
+	if c.pinned >= 0 {
+		status.Current = c.pages[c.pinned].Name
+		status.Pinned = status.Current
+	}
+	if c.alert >= 0 {
+		status.Current = c.pages[c.alert].Name
+		status.Alert = status.Current
+	}
+	return status
+}
+
+// switchDisplayMode removes what the previous page's mode left on the
+// device before a page with another mode is shown. from is "" for the first
+// page after startup.
+func switchDisplayMode(device DaemonDevice, from, to string) error {
+	if from == to {
+		return nil
+	}
+	if from == modeText {
+		if err := postDaemonCommand(device, DaemonCommandPayload{Command: "Draw/ClearHttpText"}); err != nil {
+			return err
+		}
+	}
+	switch {
+	case to == modeText:
+		// Text is drawn over the Draw/SendHttpGif picture, so start from a
+		// black one rather than whatever the device showed before
+		_, err := sendDaemonFrame(device, pixel.NewFrame(frameSize(device)), 1000)
+		return err
+	case to == modePCMonitor && from != "":
+		// Text and frames both leave the clock face
+		return postDaemonCommand(device, DaemonClockPayload{
+			Command: "Channel/SetClockSelectId",
+			ClockId: divoom.PCMonitorClockId,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCarouselRotatesAndPins(t *testing.T) {
+	pages := []PageConfig{
+		{Name: "a", Duration: Duration{10 * time.Second}},
+		{Name: "b", Duration: Duration{5 * time.Second}},
+	}
+	if err := validatePages(pages); err != nil {
+		t.Fatalf("validatePages: %v", err)
+	}
+	c := &Carousel{pinned: -1, alert: -1}
+	c.Configure(pages)
+
+	start := time.Now()
+	steps := []struct {
+		after time.Duration
+		alert string
+		want  string
+	}{
+		{0, "", "a"},
+		{9 * time.Second, "", "a"},
+		{10 * time.Second, "", "b"},
+		{12 * time.Second, "a", "a"}, // alert page takes precedence
+		{15 * time.Second, "", "a"},  // b's 5s are up
+	}
+	for _, s := range steps {
+		if got := c.Select(start.Add(s.after), s.alert).Name; got != s.want {
+			t.Errorf("after %s: page %s, want %s", s.after, got, s.want)
+		}
+	}
+
+	if err := c.Pin("b"); err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+	if got := c.Select(start.Add(time.Hour), "").Name; got != "b" {
+		t.Errorf("pinned page %s, want b", got)
+	}
+	if err := c.Pin("missing"); err == nil {
+		t.Errorf("Pin accepted an unknown page")
+	}
+
+	// Reloading keeps the pin when the page still exists
+	c.Configure(append(pages, PageConfig{Name: "c"}))
+	if status := c.Status(); status.Pinned != "b" {
+		t.Errorf("pin after Configure = %q, want b", status.Pinned)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// TickRecord is one line of a recording: the data collected on a tick,
+// the values after smoothing and everything posted to the device until the
+// next tick.
+type TickRecord struct {
+	Time     time.Time          `json:"Time"`
+	Data     DaemonHardwareData `json:"Data"`
+	Values   map[string]float64 `json:"Values"`
+	Payloads []RecordedPayload  `json:"Payloads,omitempty"`
+}
+
+// RecordedPayload is a request body as posted to URL.
+type RecordedPayload struct {
+	URL  string          `json:"URL"`
+	Body json.RawMessage `json:"Body"`
+}
+
+// Recorder writes a TickRecord per tick as JSON lines. A record is written
+// when the next tick begins, so it includes the payloads of the whole tick.
+type Recorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	enc     *json.Encoder
+	pending *TickRecord
+}
+
+// daemonRecorder is nil unless --record is given.
+var daemonRecorder *Recorder
+
+func newRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %v", err)
+	}
+	return &Recorder{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// Begin starts the record of a tick and writes the previous one.
+func (r *Recorder) Begin(now time.Time, data DaemonHardwareData, values map[string]float64) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flush()
+	r.pending = &TickRecord{Time: now, Data: data, Values: values}
+}
+
+// Payload adds a posted request body to the current record.
+func (r *Recorder) Payload(url string, body []byte) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending != nil {
+		r.pending.Payloads = append(r.pending.Payloads, RecordedPayload{
+			URL:  url,
+			Body: append(json.RawMessage(nil), body...),
+		})
+	}
+}
+
+// Close writes the last record and closes the file.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flush()
+	return r.file.Close()
+}
+
+func (r *Recorder) flush() {
+	if r.pending == nil {
+		return
+	}
+	if err := r.enc.Encode(r.pending); err != nil {
+		logger.Warn("Error writing recording", "err", err)
+	}
+	r.pending = nil
+}
+
+// DaemonSample is the hardware data of one tick and when it was collected.
+// The monitoring loop uses Time as its clock for smoothing, alerts, pages and
+// the schedule, so a replay behaves as the recorded run did at any speed.
+type DaemonSample struct {
+	Time time.Time
+	Data DaemonHardwareData
+}
+
+// collectDaemonSamples collects the hardware data every interval.
+func collectDaemonSamples(interval time.Duration) <-chan DaemonSample {
+	samples := make(chan DaemonSample)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			data := getDaemonHardwareData()
+			samples <- DaemonSample{Time: time.Now(), Data: data}
+		}
+	}()
+	return samples
+}
+
+// replayDaemonSamples reads a recording and delivers its samples with the
+// recorded gaps divided by speed. The channel is closed at the end.
+func replayDaemonSamples(path string, speed float64) (<-chan DaemonSample, error) {
+	if speed <= 0 {
+		return nil, fmt.Errorf("replay speed must be positive")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %v", err)
+	}
+
+	samples := make(chan DaemonSample)
+	go func() {
+		defer file.Close()
+		defer close(samples)
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		var last time.Time
+		for line := 1; scanner.Scan(); line++ {
+			var record TickRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				logger.Warn("Replay: skipping line", "line", line, "err", err)
+				continue
+			}
+			if !last.IsZero() && record.Time.After(last) {
+				time.Sleep(time.Duration(float64(record.Time.Sub(last)) / speed))
+			}
+			last = record.Time
+			samples <- DaemonSample{Time: record.Time, Data: record.Data}
+		}
+		if err := scanner.Err(); err != nil {
+			logger.Error("Replay: error reading recording", "err", err)
+		}
+	}()
+	return samples, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ticks.jsonl")
+	recorder, err := newRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		data := DaemonHardwareData{CpuUsage: 10 * (i + 1)}
+		recorder.Begin(start.Add(time.Duration(i)*time.Second), data, map[string]float64{"CpuUsage": float64(data.CpuUsage)})
+		recorder.Payload("http://device/post", []byte(`{"Command":"Device/UpdatePCParaInfo"}`))
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two seconds of recording at 100x
+	samples, err := replayDaemonSamples(path, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for sample := range samples {
+		got = append(got, sample.Data.CpuUsage)
+		if want := start.Add(time.Duration(len(got)-1) * time.Second); !sample.Time.Equal(want) {
+			t.Errorf("sample %d at %s, want the recorded %s", len(got), sample.Time, want)
+		}
+	}
+	if len(got) != 3 || got[0] != 10 || got[2] != 30 {
+		t.Errorf("replayed CpuUsage %v, want [10 20 30]", got)
+	}
+
+	if _, err := replayDaemonSamples(path, 0); err == nil {
+		t.Errorf("replay accepted speed 0")
+	}
+}
+
+func TestReplayAlertsOnRecordedTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ticks.jsonl")
+	recorder, err := newRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ten minutes at 90°C, one tick every 10s
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i <= 60; i++ {
+		recorder.Begin(start.Add(time.Duration(i)*10*time.Second), DaemonHardwareData{CpuTemp: 90}, nil)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// At 10000x the replay takes 60ms, the alert still needs 5 recorded minutes
+	m := newTestAlerts(t, AlertRule{Name: "hot", Metric: "CpuTemp", Threshold: 80, For: Duration{5 * time.Minute}})
+	samples, err := replayDaemonSamples(path, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var firedAt time.Duration
+	for sample := range samples {
+		for _, transition := range m.Evaluate(sample.Data, sample.Time) {
+			if transition.Firing {
+				firedAt = sample.Time.Sub(start)
+			}
+		}
+	}
+	if firedAt != 5*time.Minute {
+		t.Errorf("alert fired %s into the recording, want 5m0s", firedAt)
+	}
+}
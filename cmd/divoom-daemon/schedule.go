@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ScheduleConfig holds time-of-day rules such as a night mode.
+type ScheduleConfig struct {
+	TimeZone string         `json:"TimeZone"` // IANA name, default local time
+	Rules    []ScheduleRule `json:"Rules"`
+
+	location *time.Location
+}
+
+// ScheduleRule dims the display, turns it off or pauses updates between
+// Start and End on the given days. A range that passes midnight belongs to
+// the day it starts on.
+type ScheduleRule struct {
+	Name       string   `json:"Name"`
+	Days       []string `json:"Days"`  // Mon ... Sun, default every day
+	Start      string   `json:"Start"` // "22:00"
+	End        string   `json:"End"`   // "07:00"
+	Action     string   `json:"Action"`
+	Brightness int      `json:"Brightness"` // for dim
+
+	days       [7]bool
+	start, end int // minutes after midnight
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func (c *ScheduleConfig) validate() error {
+	c.location = time.Local
+	if c.TimeZone != "" {
+		location, err := time.LoadLocation(c.TimeZone)
+		if err != nil {
+			return fmt.Errorf("invalid time zone %q: %v", c.TimeZone, err)
+		}
+		c.location = location
+	}
+	for i := range c.Rules {
+		if err := c.Rules[i].validate(); err != nil {
+			return fmt.Errorf("rule %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (r *ScheduleRule) validate() error {
+	switch r.Action {
+	case "dim":
+		if r.Brightness < 0 || r.Brightness > 100 {
+			return fmt.Errorf("Brightness must be between 0 and 100")
+		}
+	case "off", "pause":
+	default:
+		return fmt.Errorf("unknown action %q (dim, off or pause)", r.Action)
+	}
+	if r.Name == "" {
+		r.Name = r.Action
+	}
+
+	var err error
+	if r.start, err = parseClockTime(r.Start); err != nil {
+		return err
+	}
+	if r.end, err = parseClockTime(r.End); err != nil {
+		return err
+	}
+
+	if len(r.Days) == 0 {
+		r.days = [7]bool{true, true, true, true, true, true, true}
+	}
+	for _, day := range r.Days {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return fmt.Errorf("unknown day %q", day)
+		}
+		r.days[weekday] = true
+	}
+	return nil
+}
+
+func parseClockTime(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// activeAt reports whether the rule is in effect at now. Start equal to End
+// means the whole day.
+func (r *ScheduleRule) activeAt(now time.Time) bool {
+	minute := now.Hour()*60 + now.Minute()
+	today := now.Weekday()
+	yesterday := (today + 6) % 7
+	switch {
+	case r.start < r.end:
+		return r.days[today] && minute >= r.start && minute < r.end
+	case r.start > r.end:
+		return (r.days[today] && minute >= r.start) || (r.days[yesterday] && minute < r.end)
+	default:
+		return r.days[today]
+	}
+}
+
+// Scheduler applies the first matching rule to the device and restores the
+// previous state when it ends.
+type Scheduler struct {
+	config     ScheduleConfig
+	applied    int // index of the applied rule, -1 for none
+	brightness int // brightness before a dim rule
+}
+
+func newScheduler(config ScheduleConfig) *Scheduler {
+	if config.location == nil {
+		config.location = time.Local
+	}
+	return &Scheduler{config: config, applied: -1}
+}
+
+// Update starts and ends rules as time passes. It reports whether updates
+// should be held back because the display is off or paused. A failed
+// command is retried on the next call.
+func (s *Scheduler) Update(device DaemonDevice, now time.Time) (bool, error) {
+	active := -1
+	local := now.In(s.config.location)
+	for i := range s.config.Rules {
+		if s.config.Rules[i].activeAt(local) {
+			active = i
+			break
+		}
+	}
+
+	if active != s.applied {
+		if err := s.Reset(device); err != nil {
+			return s.holding(), err
+		}
+		if active >= 0 {
+			rule := s.config.Rules[active]
+			if err := s.apply(device, rule); err != nil {
+				return false, err
+			}
+			s.applied = active
+			daemonState.SetSchedule(rule.Name)
+			logger.Info("Schedule started", "rule", rule.Name, "action", rule.Action)
+		}
+	}
+	return s.holding(), nil
+}
+
+// Reset restores the display if a rule is applied, e.g. before the
+// configuration is reloaded.
+func (s *Scheduler) Reset(device DaemonDevice) error {
+	if s.applied < 0 {
+		return nil
+	}
+	rule := s.config.Rules[s.applied]
+	if err := s.revert(device, rule); err != nil {
+		return err
+	}
+	s.applied = -1
+	daemonState.SetSchedule("")
+	logger.Info("Schedule ended", "rule", rule.Name)
+	return nil
+}
+
+func (s *Scheduler) holding() bool {
+	return s.applied >= 0 && s.config.Rules[s.applied].Action != "dim"
+}
+
+func (s *Scheduler) apply(device DaemonDevice, rule ScheduleRule) error {
+	client := deviceClient()
+	switch rule.Action {
+	case "dim":
+		// The brightness from before the rule is saved with the state, so
+		// a restart during the rule does not take the dimmed one for it
+		s.brightness = daemonState.DimRestore()
+		if s.brightness == 0 {
+			// A dry run has no device to read the brightness from;
+			// restore full brightness
+			s.brightness = 100
+			if !daemonDryRun {
+				conf, err := client.GetAllConf(device.DevicePrivateIP)
+				if err != nil {
+					return err
+				}
+				// Without a saved value, a device already at the rule's
+				// brightness was most likely dimmed by an earlier run
+				if conf.Brightness != rule.Brightness {
+					s.brightness = conf.Brightness
+				}
+			}
+			daemonState.SetDimRestore(s.brightness)
+		}
+		return client.SetBrightness(device.DevicePrivateIP, rule.Brightness)
+	case "off":
+		return client.SetScreen(device.DevicePrivateIP, false)
+	}
+	return nil
+}
+
+func (s *Scheduler) revert(device DaemonDevice, rule ScheduleRule) error {
+	client := deviceClient()
+	switch rule.Action {
+	case "dim":
+		if err := client.SetBrightness(device.DevicePrivateIP, s.brightness); err != nil {
+			return err
+		}
+		daemonState.SetDimRestore(0)
+		return nil
+	case "off":
+		if err := client.SetScreen(device.DevicePrivateIP, true); err != nil {
+			return err
+		}
+	}
+	// The display may have changed while updates were held back
+	daemonPayloads.ForgetDevice(device.DevicePrivateIP)
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleRuleOvernight(t *testing.T) {
+	rule := ScheduleRule{Days: []string{"Fri"}, Start: "22:00", End: "07:00", Action: "off"}
+	if err := rule.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	cases := []struct {
+		at     string
+		active bool
+	}{
+		{"2026-10-16 21:59", false}, // Friday evening, before start
+		{"2026-10-16 22:00", true},  // Friday night
+		{"2026-10-17 06:59", true},  // Saturday morning, range started Friday
+		{"2026-10-17 07:00", false},
+		{"2026-10-17 23:00", false}, // Saturday night is not scheduled
+		{"2026-10-18 03:00", false},
+	}
+	for _, c := range cases {
+		at, _ := time.Parse("2006-01-02 15:04", c.at)
+		if got := rule.activeAt(at); got != c.active {
+			t.Errorf("activeAt(%s %s) = %v, want %v", at.Weekday(), c.at, got, c.active)
+		}
+	}
+}
+
+func TestScheduleConfigTimeZone(t *testing.T) {
+	config := ScheduleConfig{
+		TimeZone: "America/New_York",
+		Rules:    []ScheduleRule{{Start: "09:00", End: "17:00", Action: "pause"}},
+	}
+	if err := config.validate(); err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	// 14:00 UTC is 10:00 in New York (EDT)
+	at := time.Date(2026, 10, 14, 14, 0, 0, 0, time.UTC)
+	if !config.Rules[0].activeAt(at.In(config.location)) {
+		t.Errorf("rule not active at 10:00 New York time")
+	}
+
+	config.TimeZone = "Mars/Olympus_Mons"
+	if err := config.validate(); err == nil {
+		t.Errorf("validate accepted an unknown time zone")
+	}
+}
+
+func newTestScheduler(t *testing.T, rules ...ScheduleRule) *Scheduler {
+	t.Helper()
+	state := daemonState
+	daemonState = &DaemonState{}
+	t.Cleanup(func() { daemonState = state })
+	config := ScheduleConfig{TimeZone: "UTC", Rules: rules}
+	if err := config.validate(); err != nil {
+		t.Fatal(err)
+	}
+	return newScheduler(config)
+}
+
+func TestSchedulerDimRestoresBrightness(t *testing.T) {
+	s, device := newTestDevice(t)
+	schedule := newTestScheduler(t, ScheduleRule{Name: "night", Start: "22:00", End: "07:00", Action: "dim", Brightness: 10})
+	if err := deviceClient().SetBrightness(device.DevicePrivateIP, 70); err != nil {
+		t.Fatal(err)
+	}
+
+	night := time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)
+	if hold, err := schedule.Update(device, night); hold || err != nil {
+		t.Fatalf("Update = %v, %v; dim must not hold updates", hold, err)
+	}
+	if b := s.State().Brightness; b != 10 {
+		t.Fatalf("brightness %d at night, want 10", b)
+	}
+	if _, err := schedule.Update(device, night.Add(9*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if b := s.State().Brightness; b != 70 {
+		t.Errorf("brightness %d in the morning, want 70", b)
+	}
+}
+
+func TestSchedulerDimSurvivesRestart(t *testing.T) {
+	s, device := newTestDevice(t)
+	rule := ScheduleRule{Name: "night", Start: "22:00", End: "07:00", Action: "dim", Brightness: 10}
+	schedule := newTestScheduler(t, rule)
+	if err := deviceClient().SetBrightness(device.DevicePrivateIP, 70); err != nil {
+		t.Fatal(err)
+	}
+	night := time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)
+	if _, err := schedule.Update(device, night); err != nil {
+		t.Fatal(err)
+	}
+
+	// The brightness to restore goes through the state file
+	t.Setenv("STATE_DIRECTORY", "")
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file, _, err := openStateFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Save(daemonState.Saved()); err != nil {
+		t.Fatal(err)
+	}
+	_, saved, err := openStateFile()
+	if err != nil || saved == nil {
+		t.Fatalf("openStateFile = %v, %v", saved, err)
+	}
+	daemonState = &DaemonState{}
+	daemonState.SetDimRestore(saved.DimRestore)
+
+	restarted := newScheduler(schedule.config)
+	if _, err := restarted.Update(device, night.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restarted.Update(device, night.Add(9*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if b := s.State().Brightness; b != 70 {
+		t.Errorf("brightness %d in the morning, want 70", b)
+	}
+	if daemonState.DimRestore() != 0 {
+		t.Errorf("restore value %d kept after the rule ended", daemonState.DimRestore())
+	}
+}
+
+func TestSchedulerDimWithoutSavedBrightness(t *testing.T) {
+	s, device := newTestDevice(t)
+	schedule := newTestScheduler(t, ScheduleRule{Start: "22:00", End: "07:00", Action: "dim", Brightness: 10})
+	// Dimmed by a run whose state was lost
+	if err := deviceClient().SetBrightness(device.DevicePrivateIP, 10); err != nil {
+		t.Fatal(err)
+	}
+	night := time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)
+	schedule.Update(device, night)
+	schedule.Update(device, night.Add(9*time.Hour))
+	if b := s.State().Brightness; b != 100 {
+		t.Errorf("brightness %d in the morning, want 100", b)
+	}
+}
+
+func TestSchedulerDimInDryRun(t *testing.T) {
+	s, device := newTestDevice(t)
+	daemonDryRun = true
+	defer func() { daemonDryRun = false }()
+	schedule := newTestScheduler(t, ScheduleRule{Start: "22:00", End: "07:00", Action: "dim", Brightness: 10})
+
+	night := time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)
+	schedule.Update(device, night)
+	schedule.Update(device, night.Add(9*time.Hour))
+	if got := len(s.CommandsNamed("Channel/GetAllConf")); got != 0 {
+		t.Errorf("read the brightness %d times in a dry run", got)
+	}
+	if b := s.State().Brightness; b != 100 {
+		t.Errorf("brightness %d after the dry run, want 100", b)
+	}
+}
+
+func TestSchedulerOffRetriesAndHolds(t *testing.T) {
+	s, device := newTestDevice(t)
+	schedule := newTestScheduler(t, ScheduleRule{Start: "22:00", End: "07:00", Action: "off"})
+	night := time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)
+
+	s.SetErrorCode(1)
+	if _, err := schedule.Update(device, night); err == nil {
+		t.Fatalf("screen off succeeded despite error_code 1")
+	}
+	s.SetErrorCode(0)
+	if hold, err := schedule.Update(device, night); !hold || err != nil {
+		t.Fatalf("Update = %v, %v, want hold", hold, err)
+	}
+	if s.State().LightSwitch != 0 {
+		t.Fatalf("screen still on")
+	}
+	if hold, err := schedule.Update(device, night.Add(9*time.Hour)); hold || err != nil {
+		t.Fatalf("Update in the morning = %v, %v", hold, err)
+	}
+	if s.State().LightSwitch != 1 {
+		t.Errorf("screen not turned on again")
+	}
+}
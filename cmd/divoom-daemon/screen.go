@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"image"
+
+	"divoom-monitor/internal/pixel"
+)
+
+// defaultScreen is a 64x64 dashboard with a row per metric group.
+var defaultScreen = pixel.Screen{
+	Size: 64,
+	Widgets: []pixel.Widget{
+		{Type: "icon", X: 1, Y: 1, Icon: "cpu", Color: "#00FF00"},
+		{Type: "text", X: 8, Y: 1, W: 55, Text: "{CpuUsage}% {CpuTemp}°", Align: "right", Color: "#00FF00"},
+		{Type: "sparkline", X: 0, Y: 7, W: 64, H: 10, Metric: "CpuUsage", Color: "#00FF00"},
+		{Type: "icon", X: 1, Y: 19, Icon: "gpu", Color: "#00FFFF"},
+		{Type: "text", X: 8, Y: 19, W: 55, Text: "{GpuUsage}% {GpuTemp}°", Align: "right", Color: "#00FFFF"},
+		{Type: "sparkline", X: 0, Y: 25, W: 64, H: 10, Metric: "GpuUsage", Color: "#00FFFF"},
+		{Type: "icon", X: 1, Y: 37, Icon: "memory", Color: "#FFFF00"},
+		{Type: "text", X: 8, Y: 37, W: 55, Text: "{MemoryUsage}%", Align: "right", Color: "#FFFF00"},
+		{Type: "bar", X: 0, Y: 43, W: 64, H: 4, Metric: "MemoryUsage", Color: "#FFFF00"},
+		{Type: "icon", X: 1, Y: 52, Icon: "disk", Color: "#FF8000"},
+		{Type: "text", X: 8, Y: 52, W: 55, Text: "{DiskTemp}°", Align: "right", Color: "#FF8000"},
+	},
+}
+
+// validateScreen checks the screen layout and that its widgets only refer
+// to known metrics. A Size of 0 is left for pagesForDevice to fill in and
+// checked as the largest frame until then.
+func validateScreen(screen *pixel.Screen) error {
+	deviceSize := screen.Size == 0
+	if err := screen.Validate(); err != nil {
+		return err
+	}
+	if deviceSize {
+		screen.Size = 0
+	}
+	for i, w := range screen.Widgets {
+		if w.Metric != "" && !isMetricName(w.Metric) {
+			return fmt.Errorf("widget %d: unknown metric %q", i+1, w.Metric)
+		}
+		if err := validateMetricTemplate(w.Text); err != nil {
+			return fmt.Errorf("widget %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// ScreenRenderer draws a pixel.Screen from the latest values and keeps the
+// history its sparklines show.
+type ScreenRenderer struct {
+	screen  pixel.Screen
+	values  map[string]float64
+	history map[string][]float64
+}
+
+func newScreenRenderer(screen pixel.Screen) *ScreenRenderer {
+	if len(screen.Widgets) == 0 {
+		screen = defaultScreen
+		screen.Widgets = append([]pixel.Widget(nil), defaultScreen.Widgets...)
+		// Fills in the widget defaults; TestDefaultScreen keeps it passing
+		if err := validateScreen(&screen); err != nil {
+			fatal("Invalid default screen", "error", err)
+		}
+	}
+	return &ScreenRenderer{screen: screen, history: make(map[string][]float64)}
+}
+
+// Add records one sample. Like GraphRenderer.Add it is called every tick.
+func (r *ScreenRenderer) Add(values map[string]float64) {
+	r.values = values
+	added := make(map[string]bool)
+	for _, w := range r.screen.Widgets {
+		if w.Type != "sparkline" || added[w.Metric] {
+			continue
+		}
+		added[w.Metric] = true
+		history := append(r.history[w.Metric], values[w.Metric])
+		if len(history) > r.screen.Size {
+			history = history[len(history)-r.screen.Size:]
+		}
+		r.history[w.Metric] = history
+	}
+}
+
+func (r *ScreenRenderer) Render() *image.RGBA {
+	return r.screen.Render(r)
+}
+
+func (r *ScreenRenderer) Text(template string) string {
+	return expandMetricTemplate(template, r.values)
+}
+
+func (r *ScreenRenderer) Value(metric string) float64 {
+	return r.values[metric]
+}
+
+func (r *ScreenRenderer) History(metric string) []float64 {
+	return r.history[metric]
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+
+	"divoom-monitor/internal/pixel"
+)
+
+func TestDefaultScreen(t *testing.T) {
+	screen := defaultScreen
+	screen.Widgets = append([]pixel.Widget(nil), defaultScreen.Widgets...)
+	if err := validateScreen(&screen); err != nil {
+		t.Fatalf("default screen: %v", err)
+	}
+
+	// A page without widgets draws the default screen
+	r := newScreenRenderer(pixel.Screen{})
+	r.Add(map[string]float64{"CpuUsage": 50, "MemoryUsage": 25})
+	if size := r.Render().Bounds().Dx(); size != defaultScreen.Size {
+		t.Errorf("rendered %d pixels wide, want %d", size, defaultScreen.Size)
+	}
+}
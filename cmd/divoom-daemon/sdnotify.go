@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ServiceNotifier reports readiness, status and liveness to systemd
+// through $NOTIFY_SOCKET (sd_notify). Without the variable, e.g. when not
+// started by a Type=notify unit, it does nothing.
+type ServiceNotifier struct {
+	socket     string
+	watchdog   time.Duration // WatchdogSec, 0 if disabled
+	interval   time.Duration // time between samples
+	lastStatus string
+	busySince  atomic.Int64 // UnixNano the loop started its current tick or signal, 0 while it waits
+	inTick     atomic.Bool
+	lastTick   atomic.Int64 // UnixNano the loop last finished a tick
+}
+
+func newServiceNotifier() *ServiceNotifier {
+	n := &ServiceNotifier{socket: os.Getenv("NOTIFY_SOCKET")}
+	// WATCHDOG_PID, if set, names the process that must send the pings
+	if pid := os.Getenv("WATCHDOG_PID"); pid == "" || pid == strconv.Itoa(os.Getpid()) {
+		if usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64); err == nil && usec > 0 {
+			n.watchdog = time.Duration(usec) * time.Microsecond
+		}
+	}
+	return n
+}
+
+// Ready tells systemd that startup has finished.
+func (n *ServiceNotifier) Ready(status string) {
+	n.lastStatus = status
+	n.send("READY=1\nSTATUS=" + status)
+}
+
+// Reloading is sent before the configuration is reloaded; Ready follows.
+func (n *ServiceNotifier) Reloading() {
+	n.send("RELOADING=1")
+}
+
+// Stopping is sent when shutting down.
+func (n *ServiceNotifier) Stopping() {
+	n.send("STOPPING=1")
+}
+
+// Status updates the status line if it changed.
+func (n *ServiceNotifier) Status(status string) {
+	if status != n.lastStatus {
+		n.lastStatus = status
+		n.send("STATUS=" + status)
+	}
+}
+
+// Tick is called when the monitoring loop starts handling a sample, Busy
+// when it handles a signal and Idle when it waits again.
+func (n *ServiceNotifier) Tick(now time.Time) {
+	n.busySince.Store(now.UnixNano())
+	n.inTick.Store(true)
+}
+
+func (n *ServiceNotifier) Busy(now time.Time) {
+	n.busySince.Store(now.UnixNano())
+}
+
+func (n *ServiceNotifier) Idle(now time.Time) {
+	if n.inTick.Swap(false) {
+		n.lastTick.Store(now.UnixNano())
+	}
+	n.busySince.Store(0)
+}
+
+// stuck explains why the loop counts as hung, or returns "" while it is
+// alive. One tick may take several device timeouts, so it is allowed
+// WatchdogSec; the next tick is due an interval after the last one finished,
+// and may be WatchdogSec late before the sample collection counts as hung.
+func (n *ServiceNotifier) stuck(now time.Time) string {
+	if since := n.busySince.Load(); since != 0 {
+		if busy := now.Sub(time.Unix(0, since)); busy >= n.watchdog {
+			return "busy for " + busy.Round(time.Second).String()
+		}
+		return ""
+	}
+	if idle := now.Sub(time.Unix(0, n.lastTick.Load())); idle >= n.interval+n.watchdog {
+		return "no sample for " + idle.Round(time.Second).String()
+	}
+	return ""
+}
+
+// StartWatchdog pings the watchdog every WatchdogSec/2 while the loop is
+// alive, until the returned function is called. interval is the time
+// between samples.
+func (n *ServiceNotifier) StartWatchdog(interval time.Duration) (stop func()) {
+	if n.watchdog <= 0 {
+		return func() {}
+	}
+	n.interval = interval
+	n.lastTick.Store(time.Now().UnixNano())
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(n.watchdog / 2)
+		defer ticker.Stop()
+		stuck := false
+		for {
+			select {
+			case now := <-ticker.C:
+				if reason := n.stuck(now); reason == "" {
+					if stuck {
+						logger.Info("Monitoring loop recovered, pinging the watchdog again")
+					}
+					stuck = false
+					n.send("WATCHDOG=1")
+				} else if !stuck {
+					stuck = true
+					logger.Error("Monitoring loop is stuck, no longer pinging the watchdog", "reason", reason)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (n *ServiceNotifier) send(state string) {
+	if n.socket == "" {
+		return
+	}
+	// A name starting with @ is an abstract socket, which net handles
+	conn, err := net.Dial("unixgram", n.socket)
+	if err != nil {
+		logger.Warn("sd_notify failed", "err", err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		logger.Warn("sd_notify failed", "err", err)
+	}
+}
+
+// daemonStatusLine summarises the daemon state for systemctl status.
+func daemonStatusLine() string {
+	status := daemonState.Status()
+	device := status.Device.DevicePrivateIP
+	if status.Device.DeviceName != "" {
+		device = fmt.Sprintf("%s (%s)", status.Device.DeviceName, status.Device.DevicePrivateIP)
+	}
+	target := fmt.Sprintf("%s LCD %d", device, status.LcdId)
+
+	switch {
+	case status.Paused:
+		return target + ": paused"
+	case status.Locked:
+		return target + ": session locked"
+	case status.Schedule != "":
+		return fmt.Sprintf("%s: schedule %s", target, status.Schedule)
+	case status.LastError != "":
+		return fmt.Sprintf("%s: last send failed: %s", target, status.LastError)
+	case status.LastSend != nil:
+		return fmt.Sprintf("%s: last send %s", target, status.LastSend.Format("15:04:05"))
+	default:
+		return target + ": waiting for the first send"
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenNotify stands in for systemd's $NOTIFY_SOCKET.
+func listenNotify(t *testing.T, watchdog time.Duration) *net.UnixConn {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "divoom-notify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	t.Setenv("NOTIFY_SOCKET", path)
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", strconv.FormatInt(watchdog.Microseconds(), 10))
+	return conn
+}
+
+// pings counts the WATCHDOG=1 messages received within d.
+func pings(conn *net.UnixConn, d time.Duration) int {
+	count := 0
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(d))
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return count
+		}
+		if strings.Contains(string(buf[:n]), "WATCHDOG=1") {
+			count++
+		}
+	}
+}
+
+func TestWatchdogLiveness(t *testing.T) {
+	n := &ServiceNotifier{watchdog: 30 * time.Second, interval: time.Minute}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	n.lastTick.Store(start.UnixNano())
+
+	steps := []struct {
+		after  time.Duration
+		action string // "tick", "signal", "idle" or ""
+		stuck  bool
+	}{
+		{50 * time.Second, "", false}, // waiting longer than WatchdogSec for the next sample is fine
+		{60 * time.Second, "tick", false},
+		{85 * time.Second, "", false},
+		{90 * time.Second, "", true}, // one tick has taken WatchdogSec
+		{95 * time.Second, "idle", false},
+		{184 * time.Second, "", false},
+		{185 * time.Second, "", true}, // no sample an interval and WatchdogSec after the last tick
+		{186 * time.Second, "signal", false},
+		{187 * time.Second, "idle", true}, // a signal is not a tick
+		{190 * time.Second, "tick", false},
+		{191 * time.Second, "idle", false},
+	}
+	for _, step := range steps {
+		now := start.Add(step.after)
+		switch step.action {
+		case "tick":
+			n.Tick(now)
+		case "signal":
+			n.Busy(now)
+		case "idle":
+			n.Idle(now)
+		}
+		if reason := n.stuck(now); (reason != "") != step.stuck {
+			t.Errorf("at %s after %q: stuck %q, want stuck=%v", step.after, step.action, reason, step.stuck)
+		}
+	}
+}
+
+func TestWatchdogPings(t *testing.T) {
+	conn := listenNotify(t, 200*time.Millisecond)
+	service := newServiceNotifier()
+	stop := service.StartWatchdog(100 * time.Millisecond)
+	defer stop()
+
+	// Samples arrive every 100ms
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(100 * time.Millisecond):
+				service.Tick(time.Now())
+				service.Idle(time.Now())
+			}
+		}
+	}()
+	n := pings(conn, 450*time.Millisecond)
+	close(done)
+	if n < 3 {
+		t.Errorf("%d pings in 450ms with WatchdogSec 200ms", n)
+	}
+
+	// Then the sample collection stalls
+	pings(conn, 350*time.Millisecond)
+	if n := pings(conn, 300*time.Millisecond); n != 0 {
+		t.Errorf("%d pings without samples", n)
+	}
+}
+
+func TestServiceStatusOnlyWhenChanged(t *testing.T) {
+	conn := listenNotify(t, 0)
+	service := newServiceNotifier()
+	service.Status("sending")
+	service.Status("sending")
+	service.Status("paused")
+
+	var got []string
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			break
+		}
+		got = append(got, string(buf[:n]))
+	}
+	if strings.Join(got, ",") != "STATUS=sending,STATUS=paused" {
+		t.Errorf("sent %q", got)
+	}
+	if service.watchdog != 0 {
+		t.Errorf("watchdog %s without WATCHDOG_USEC", service.watchdog)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionLock tells whether the user's graphical session is locked, from
+// the LockedHint that screen lockers set through logind. The session is
+// $XDG_SESSION_ID or, for a user service which has none, the display
+// session of the user.
+type SessionLock struct {
+	session  string
+	warned   bool
+	locked   bool
+	checked  time.Time
+	loginctl func(args ...string) (string, error)
+}
+
+// sessionLockCheckInterval is how long a lock state read from logind is
+// used, so loginctl does not run on every tick.
+const sessionLockCheckInterval = 10 * time.Second
+
+func newSessionLock() *SessionLock {
+	return &SessionLock{session: os.Getenv("XDG_SESSION_ID"), loginctl: loginctl}
+}
+
+// Locked returns the lock state, reading it again once it is
+// sessionLockCheckInterval old.
+func (l *SessionLock) Locked(now time.Time) bool {
+	if !l.checked.IsZero() && !now.Before(l.checked) && now.Sub(l.checked) < sessionLockCheckInterval {
+		return l.locked
+	}
+	l.checked = now
+	l.locked = l.read()
+	return l.locked
+}
+
+// read returns false if the lock state cannot be read, e.g. before the
+// user has logged in graphically, so updates carry on.
+func (l *SessionLock) read() bool {
+	if l.session == "" {
+		display, err := l.loginctl("show-user", strconv.Itoa(os.Getuid()), "--property=Display", "--value")
+		if err != nil || display == "" {
+			l.warn("No graphical session found", err)
+			return false
+		}
+		l.session = display
+	}
+
+	hint, err := l.loginctl("show-session", l.session, "--property=LockedHint", "--value")
+	if err != nil {
+		l.warn("Cannot read session lock state", err)
+		// The session may have ended; look it up again next time
+		if os.Getenv("XDG_SESSION_ID") == "" {
+			l.session = ""
+		}
+		return false
+	}
+	l.warned = false
+	return hint == "yes"
+}
+
+// warn logs the first failure after a success only.
+func (l *SessionLock) warn(msg string, err error) {
+	if l.warned {
+		return
+	}
+	l.warned = true
+	if err != nil {
+		logger.Warn(msg, "session", l.session, "err", err)
+	} else {
+		logger.Warn(msg)
+	}
+}
+
+func loginctl(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "loginctl", args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
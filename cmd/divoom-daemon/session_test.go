@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSessionLockCachesState(t *testing.T) {
+	var calls []string
+	hint, display := "no", "c2"
+	lock := &SessionLock{loginctl: func(args ...string) (string, error) {
+		calls = append(calls, args[0])
+		if args[0] == "show-user" {
+			return display, nil
+		}
+		return hint, nil
+	}}
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	if lock.Locked(start) {
+		t.Fatalf("locked at start")
+	}
+	if len(calls) != 2 || calls[0] != "show-user" || calls[1] != "show-session" {
+		t.Fatalf("first check ran %v", calls)
+	}
+
+	// Ticks within the check interval use the state read before
+	hint = "yes"
+	for d := 3 * time.Second; d < sessionLockCheckInterval; d += 3 * time.Second {
+		if lock.Locked(start.Add(d)) {
+			t.Fatalf("lock noticed after %s, before the next check", d)
+		}
+	}
+	if len(calls) != 2 {
+		t.Errorf("loginctl ran %d times within the check interval, want 2", len(calls))
+	}
+	if !lock.Locked(start.Add(sessionLockCheckInterval)) {
+		t.Errorf("lock not noticed at the next check")
+	}
+	if len(calls) != 3 {
+		t.Errorf("the display session was looked up again: %v", calls)
+	}
+}
+
+func TestSessionLockWithoutGraphicalSession(t *testing.T) {
+	calls := 0
+	lock := &SessionLock{loginctl: func(args ...string) (string, error) {
+		calls++
+		return "", errors.New("exit status 1")
+	}}
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	for d := time.Duration(0); d < sessionLockCheckInterval; d += time.Second {
+		if lock.Locked(start.Add(d)) {
+			t.Fatalf("locked without a session")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("looked for a session %d times within the check interval, want 1", calls)
+	}
+}
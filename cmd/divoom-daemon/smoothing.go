@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// SmoothingConfig selects how one metric is smoothed before it is shown.
+type SmoothingConfig struct {
+	Method string  `json:"Method"` // ema, average or max
+	Alpha  float64 `json:"Alpha"`  // ema weight of the newest sample (0-1]
+	Window int     `json:"Window"` // number of samples for average and max
+}
+
+func (c *SmoothingConfig) validate() error {
+	switch c.Method {
+	case "ema":
+		if c.Alpha <= 0 || c.Alpha > 1 {
+			return fmt.Errorf("ema needs an Alpha between 0 and 1")
+		}
+	case "average", "max":
+		if c.Window < 1 {
+			return fmt.Errorf("%s needs a Window of at least 1 sample", c.Method)
+		}
+	default:
+		return fmt.Errorf("unknown smoothing method %q", c.Method)
+	}
+	return nil
+}
+
+// StatsConfig sets the window for the rolling Min/Max/Avg metrics.
+type StatsConfig struct {
+	Window Duration `json:"Window"`
+}
+
+type smoother interface {
+	Add(v float64) float64
+}
+
+type emaSmoother struct {
+	alpha   float64
+	value   float64
+	started bool
+}
+
+func (s *emaSmoother) Add(v float64) float64 {
+	if !s.started {
+		s.value, s.started = v, true
+	} else {
+		s.value = s.alpha*v + (1-s.alpha)*s.value
+	}
+	return s.value
+}
+
+type windowSmoother struct {
+	max    bool
+	window int
+	values []float64
+}
+
+func (s *windowSmoother) Add(v float64) float64 {
+	s.values = append(s.values, v)
+	if len(s.values) > s.window {
+		s.values = s.values[len(s.values)-s.window:]
+	}
+
+	result, sum := s.values[0], 0.0
+	for _, value := range s.values {
+		result = max(result, value)
+		sum += value
+	}
+	if s.max {
+		return result
+	}
+	return sum / float64(len(s.values))
+}
+
+type timedValue struct {
+	at    time.Time
+	value float64
+}
+
+// MetricPipeline smooths each sample and keeps a rolling history from which
+// the derived <Metric>Min, <Metric>Max and <Metric>Avg values are computed.
+type MetricPipeline struct {
+	smoothers   map[string]smoother
+	statsWindow time.Duration
+	history     map[string][]timedValue
+}
+
+func newMetricPipeline(smoothing map[string]SmoothingConfig, stats StatsConfig) *MetricPipeline {
+	p := &MetricPipeline{
+		smoothers:   make(map[string]smoother),
+		statsWindow: stats.Window.Duration,
+		history:     make(map[string][]timedValue),
+	}
+	if p.statsWindow <= 0 {
+		p.statsWindow = 5 * time.Minute
+	}
+	for name, c := range smoothing {
+		switch c.Method {
+		case "ema":
+			p.smoothers[name] = &emaSmoother{alpha: c.Alpha}
+		case "average", "max":
+			p.smoothers[name] = &windowSmoother{max: c.Method == "max", window: c.Window}
+		}
+	}
+	return p
+}
+
+// Process returns the smoothed sample together with all values, base and
+// derived, that layouts can refer to by name.
+func (p *MetricPipeline) Process(data DaemonHardwareData, now time.Time) (DaemonHardwareData, map[string]float64) {
+	values := make(map[string]float64)
+	for _, name := range daemonMetricNames {
+		value, _ := data.Metric(name)
+		if s, ok := p.smoothers[name]; ok {
+			value = s.Add(value)
+			data.SetMetric(name, value)
+		}
+		values[name] = value
+
+		history := append(p.history[name], timedValue{at: now, value: value})
+		for len(history) > 1 && now.Sub(history[0].at) > p.statsWindow {
+			history = history[1:]
+		}
+		p.history[name] = history
+
+		lo, hi, sum := history[0].value, history[0].value, 0.0
+		for _, h := range history {
+			lo = min(lo, h.value)
+			hi = max(hi, h.value)
+			sum += h.value
+		}
+		values[name+"Min"] = lo
+		values[name+"Max"] = hi
+		values[name+"Avg"] = sum / float64(len(history))
+	}
+	return data, values
+}
+
+// isMetricName reports whether name is a base or derived metric.
+func isMetricName(name string) bool {
+	for _, base := range daemonMetricNames {
+		switch name {
+		case base, base + "Min", base + "Max", base + "Avg":
+			return true
+		}
+	}
+	return false
+}
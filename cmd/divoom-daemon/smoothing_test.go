@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSmoothers(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SmoothingConfig
+		in     []float64
+		want   []float64
+	}{
+		{"ema", SmoothingConfig{Method: "ema", Alpha: 0.5}, []float64{10, 20, 20, 0}, []float64{10, 15, 17.5, 8.75}},
+		{"ema alpha 1", SmoothingConfig{Method: "ema", Alpha: 1}, []float64{10, 20, 5}, []float64{10, 20, 5}},
+		{"average", SmoothingConfig{Method: "average", Window: 3}, []float64{3, 6, 9, 12}, []float64{3, 4.5, 6, 9}},
+		{"max", SmoothingConfig{Method: "max", Window: 2}, []float64{5, 1, 3, 2}, []float64{5, 5, 3, 3}},
+		{"window 1", SmoothingConfig{Method: "average", Window: 1}, []float64{5, 1}, []float64{5, 1}},
+	}
+	for _, tt := range tests {
+		if err := tt.config.validate(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		p := newMetricPipeline(map[string]SmoothingConfig{"CpuUsage": tt.config}, StatsConfig{})
+		s := p.smoothers["CpuUsage"]
+		for i, v := range tt.in {
+			if got := s.Add(v); math.Abs(got-tt.want[i]) > 1e-9 {
+				t.Errorf("%s: sample %d: got %g, want %g", tt.name, i, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestSmoothingConfigValidate(t *testing.T) {
+	for _, bad := range []SmoothingConfig{
+		{Method: "ema"},
+		{Method: "ema", Alpha: 1.5},
+		{Method: "average"},
+		{Method: "max", Window: -1},
+		{Method: "median", Window: 3},
+	} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("validate accepted %+v", bad)
+		}
+	}
+}
+
+func TestMetricPipeline(t *testing.T) {
+	p := newMetricPipeline(map[string]SmoothingConfig{
+		"CpuUsage": {Method: "average", Window: 2},
+	}, StatsConfig{Window: Duration{time.Minute}})
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		after         time.Duration
+		cpu, gpu      int
+		wantCpu       float64
+		min, max, avg float64 // GpuUsage
+	}{
+		{0, 10, 50, 10, 50, 50, 50},
+		{20 * time.Second, 30, 70, 20, 50, 70, 60},
+		{40 * time.Second, 30, 30, 30, 30, 70, 50},
+		{90 * time.Second, 50, 40, 40, 30, 40, 35}, // the first two samples are older than a minute
+	}
+	for _, tt := range tests {
+		_, values := p.Process(DaemonHardwareData{CpuUsage: tt.cpu, GpuUsage: tt.gpu}, start.Add(tt.after))
+		if values["CpuUsage"] != tt.wantCpu {
+			t.Errorf("at %s: CpuUsage %g, want %g", tt.after, values["CpuUsage"], tt.wantCpu)
+		}
+		if values["GpuUsageMin"] != tt.min || values["GpuUsageMax"] != tt.max || values["GpuUsageAvg"] != tt.avg {
+			t.Errorf("at %s: GpuUsage min/max/avg %g/%g/%g, want %g/%g/%g", tt.after,
+				values["GpuUsageMin"], values["GpuUsageMax"], values["GpuUsageAvg"], tt.min, tt.max, tt.avg)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// DaemonState is the runtime state shared between the monitoring loop and
+// the control API.
+type DaemonState struct {
+	mu         sync.Mutex
+	device     DaemonDevice
+	lcdId      int
+	autoDetect bool
+	paused     bool
+	locked     bool
+	schedule   string
+	dimRestore int
+	lastSend   time.Time
+	lastError  string
+}
+
+var daemonState = &DaemonState{}
+
+// Target returns the device and LCD updates are currently sent to.
+func (s *DaemonState) Target() (DaemonDevice, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.device, s.lcdId
+}
+
+// SetTarget switches the device and LCD updates are sent to.
+func (s *DaemonState) SetTarget(device DaemonDevice, lcdId int) {
+	s.mu.Lock()
+	s.device = device
+	s.lcdId = lcdId
+	s.mu.Unlock()
+	daemonMetrics.SetTarget(device, lcdId)
+}
+
+// SetLcd changes only the LCD of the current target.
+func (s *DaemonState) SetLcd(lcdId int) {
+	s.mu.Lock()
+	s.lcdId = lcdId
+	device := s.device
+	s.mu.Unlock()
+	daemonMetrics.SetTarget(device, lcdId)
+}
+
+func (s *DaemonState) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+func (s *DaemonState) SetPaused(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = paused
+}
+
+// Locked reports whether updates are held because the session is locked.
+func (s *DaemonState) Locked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.locked
+}
+
+func (s *DaemonState) SetLocked(locked bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locked = locked
+}
+
+// SetSchedule records the name of the active schedule rule, "" for none.
+func (s *DaemonState) SetSchedule(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedule = name
+}
+
+// DimRestore returns the brightness to restore when a dim schedule rule
+// ends, 0 if none is recorded.
+func (s *DaemonState) DimRestore() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dimRestore
+}
+
+func (s *DaemonState) SetDimRestore(brightness int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dimRestore = brightness
+}
+
+// RecordSend remembers the outcome of the last send for status queries.
+func (s *DaemonState) RecordSend(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSend = time.Now()
+	if err != nil {
+		s.lastError = err.Error()
+	} else {
+		s.lastError = ""
+	}
+}
+
+// DaemonStatus is the JSON document returned by the status endpoint.
+type DaemonStatus struct {
+	Version   string       `json:"Version"`
+	Device    DaemonDevice `json:"Device"`
+	Model     string       `json:"Model,omitempty"`
+	LcdId     int          `json:"LcdId"`
+	Paused    bool         `json:"Paused"`
+	Locked    bool         `json:"Locked,omitempty"`
+	Schedule  string       `json:"Schedule,omitempty"`
+	LastSend  *time.Time   `json:"LastSend,omitempty"`
+	LastError string       `json:"LastError,omitempty"`
+}
+
+func (s *DaemonState) Status() DaemonStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := DaemonStatus{
+		Version:   version,
+		Device:    s.device,
+		LcdId:     s.lcdId,
+		Paused:    s.paused,
+		Locked:    s.locked,
+		Schedule:  s.schedule,
+		LastError: s.lastError,
+	}
+	if model, ok := deviceModel(s.device); ok {
+		status.Model = model.Name
+	}
+	if !s.lastSend.IsZero() {
+		lastSend := s.lastSend
+		status.LastSend = &lastSend
+	}
+	return status
+}
+
+// Rediscover runs device discovery again. If the current device is still
+// listed its record is refreshed (its IP may have changed); otherwise an
+// auto-detected target falls back to the first device found.
+func (s *DaemonState) Rediscover() ([]DaemonDevice, error) {
+	devices, err := findDaemonDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	current, lcdId, autoDetect := s.device, s.lcdId, s.autoDetect
+	s.mu.Unlock()
+
+	for _, device := range devices {
+		if current.DeviceId != 0 && device.DeviceId == current.DeviceId {
+			if device.DevicePrivateIP != current.DevicePrivateIP {
+				logger.Info("Device moved", "name", device.DeviceName, "from", current.DevicePrivateIP, "to", device.DevicePrivateIP)
+			}
+			s.SetTarget(device, lcdId)
+			return devices, nil
+		}
+	}
+
+	if autoDetect && len(devices) > 0 {
+		logger.Info("Switching device", "name", devices[0].DeviceName, "device", devices[0].DevicePrivateIP)
+		if err := checkLcd(devices[0], lcdId); err != nil {
+			logger.Warn("LCD not valid for the new device", "err", err)
+		}
+		s.SetTarget(devices[0], lcdId)
+	}
+	return devices, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// The device accepts text ids 0-19. Id 0 is kept for one-off messages, the
+// API's /text and alert banners, so they and the text mode lines do not
+// overwrite each other; line i is sent with TextId firstLineTextId+i.
+const (
+	messageTextId   = 0
+	firstLineTextId = 1
+	maxTextLines    = 19
+)
+
+var textColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// TextLine is one line of the text display mode. Template uses the same
+// {Metric} placeholders as Layout.
+type TextLine struct {
+	Template string `json:"Template"`
+	X        int    `json:"X"`
+	Y        int    `json:"Y"`
+	Dir      int    `json:"Dir"`   // scroll direction: 0 left, 1 right
+	Font     int    `json:"Font"`  // device font id (0-7)
+	Width    int    `json:"Width"` // text area width in pixels (16-64)
+	Speed    int    `json:"Speed"` // scroll step in milliseconds
+	Color    string `json:"Color"`
+	Align    int    `json:"Align"` // 1 left, 2 middle, 3 right
+}
+
+// defaultTextLines shows the default metrics as four lines on a 64x64 panel.
+var defaultTextLines = []TextLine{
+	{Template: "CPU {CpuUsage}% {CpuTemp}C", Y: 0, Width: 64, Speed: 100, Color: "#00FF00", Align: 1},
+	{Template: "GPU {GpuUsage}% {GpuTemp}C", Y: 16, Width: 64, Speed: 100, Color: "#00FFFF", Align: 1},
+	{Template: "MEM {MemoryUsage}%", Y: 32, Width: 64, Speed: 100, Color: "#FFFF00", Align: 1},
+	{Template: "DSK {DiskTemp}C", Y: 48, Width: 64, Speed: 100, Color: "#FF8000", Align: 1},
+}
+
+func (l *TextLine) validate() error {
+	if err := validateMetricTemplate(l.Template); err != nil {
+		return err
+	}
+	if l.Width == 0 {
+		l.Width = 64
+	}
+	if l.Speed == 0 {
+		l.Speed = 100
+	}
+	if l.Color == "" {
+		l.Color = "#FFFFFF"
+	}
+	if l.Align == 0 {
+		l.Align = 1
+	}
+	switch {
+	case l.X < 0 || l.X > 63 || l.Y < 0 || l.Y > 63:
+		return fmt.Errorf("position %d,%d is outside the 64x64 panel", l.X, l.Y)
+	case l.Dir != 0 && l.Dir != 1:
+		return fmt.Errorf("Dir must be 0 (left) or 1 (right)")
+	case l.Font < 0 || l.Font > 7:
+		return fmt.Errorf("Font must be between 0 and 7")
+	case l.Width < 16 || l.Width > 64:
+		return fmt.Errorf("Width must be between 16 and 64")
+	case l.Speed < 0:
+		return fmt.Errorf("Speed must not be negative")
+	case !textColorPattern.MatchString(l.Color):
+		return fmt.Errorf("Color must look like #RRGGBB")
+	case l.Align < 1 || l.Align > 3:
+		return fmt.Errorf("Align must be 1 (left), 2 (middle) or 3 (right)")
+	}
+	return nil
+}
+
+func validateTextLines(lines []TextLine) error {
+	if len(lines) > maxTextLines {
+		return fmt.Errorf("text mode has %d lines, the device supports %d", len(lines), maxTextLines)
+	}
+	for i := range lines {
+		if err := lines[i].validate(); err != nil {
+			return fmt.Errorf("text line %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// sendDaemonTextLines updates every text line whose rendered payload changed
+// and reports whether anything was posted.
+func sendDaemonTextLines(device DaemonDevice, lines []TextLine, values map[string]float64) (bool, error) {
+	if len(lines) == 0 {
+		lines = defaultTextLines
+	}
+
+	sent := false
+	now := time.Now()
+	for i, line := range lines {
+		payload := DaemonTextPayload{
+			Command:    "Draw/SendHttpText",
+			TextId:     firstLineTextId + i,
+			X:          line.X,
+			Y:          line.Y,
+			Dir:        line.Dir,
+			Font:       line.Font,
+			TextWidth:  line.Width,
+			Speed:      line.Speed,
+			TextString: expandMetricTemplate(line.Template, values),
+			Color:      line.Color,
+			Align:      line.Align,
+		}
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return sent, err
+		}
+
+		key := fmt.Sprintf("%s/text/%d", device.DevicePrivateIP, payload.TextId)
+		if daemonPayloads.Unchanged(key, jsonData, now) {
+			continue
+		}
+		sent = true
+		if err := postDaemonJSON(device, jsonData); err != nil {
+			return sent, err
+		}
+		daemonPayloads.Acknowledge(key, jsonData, now)
+	}
+	return sent, nil
+}
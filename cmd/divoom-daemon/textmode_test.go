@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	"divoom-monitor/internal/divoom"
+)
+
+func TestTextLinesAndMessagesUseSeparateIds(t *testing.T) {
+	s, device := newTestDevice(t)
+	lines := []TextLine{{Template: "CPU {CpuUsage}%"}, {Template: "MEM {MemoryUsage}%", Y: 16}}
+	if err := validateTextLines(lines); err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]float64{"CpuUsage": 12, "MemoryUsage": 34}
+	if sent, err := sendDaemonTextLines(device, lines, values); !sent || err != nil {
+		t.Fatalf("sendDaemonTextLines = %v, %v", sent, err)
+	}
+
+	// Unchanged lines are not sent again
+	before := len(s.CommandsNamed("Draw/SendHttpText"))
+	if sent, err := sendDaemonTextLines(device, lines, values); sent || err != nil {
+		t.Errorf("unchanged lines: sent=%v err=%v", sent, err)
+	}
+	if after := len(s.CommandsNamed("Draw/SendHttpText")); after != before {
+		t.Errorf("%d lines resent", after-before)
+	}
+
+	// A message does not replace any of the lines
+	if err := sendDaemonText(device, "Backup done", ""); err != nil {
+		t.Fatal(err)
+	}
+	texts := s.State().Text
+	if texts[firstLineTextId] != "CPU 12%" || texts[firstLineTextId+1] != "MEM 34%" || texts[messageTextId] != "Backup done" {
+		t.Errorf("device shows %v", texts)
+	}
+}
+
+func TestTextLineLimit(t *testing.T) {
+	lines := make([]TextLine, maxTextLines+1)
+	if err := validateTextLines(lines); err == nil {
+		t.Errorf("accepted %d lines", len(lines))
+	}
+	if firstLineTextId+maxTextLines-1 > 19 {
+		t.Errorf("text mode lines go up to TextId %d, the device accepts 0-19", firstLineTextId+maxTextLines-1)
+	}
+}
+
+func TestSwitchDisplayMode(t *testing.T) {
+	s, device := newTestDevice(t)
+	device.DeviceName, device.Hardware = "Pixoo64", 0
+
+	// Entering text mode puts a black picture behind the text
+	if err := switchDisplayMode(device, "", modeText); err != nil {
+		t.Fatal(err)
+	}
+	gifs := s.CommandsNamed("Draw/SendHttpGif")
+	if len(gifs) != 1 {
+		t.Fatalf("%d frames sent when entering text mode, want 1", len(gifs))
+	}
+	var frame DaemonGifPayload
+	if err := gifs[0].Decode(&frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.PicWidth != 64 {
+		t.Errorf("background frame is %d wide, want 64", frame.PicWidth)
+	}
+
+	// Leaving it clears the text and goes back to the PC monitor face
+	sendDaemonText(device, "hello", "")
+	s.Device.Reset()
+	deviceClient().SetClock(device.DevicePrivateIP, 12)
+	if err := switchDisplayMode(device, modeText, modePCMonitor); err != nil {
+		t.Fatal(err)
+	}
+	state := s.State()
+	if len(state.Text) != 0 {
+		t.Errorf("text left on the device: %v", state.Text)
+	}
+	if state.CurClockId != divoom.PCMonitorClockId {
+		t.Errorf("clock %d, want %d", state.CurClockId, divoom.PCMonitorClockId)
+	}
+
+	// The first page after startup leaves the clock face alone
+	s.Device.Reset()
+	if err := switchDisplayMode(device, "", modePCMonitor); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.Commands()); n != 0 {
+		t.Errorf("%d commands for the first pcmonitor page", n)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const userUnitName = "divoom-monitor.service"
+
+// userUnitTemplate runs the daemon in the user's session manager. %t is the
+// runtime directory, so the control API socket is
+// $XDG_RUNTIME_DIR/divoom-monitor.sock.
+const userUnitTemplate = `[Unit]
+Description=Divoom PC Monitor (user session)
+Documentation=https://github.com/alessio/divoom-pcmonitor-Linux
+
+[Service]
+Type=notify
+NotifyAccess=main
+WatchdogSec=30
+ExecStart=%s
+ExecReload=/bin/kill -HUP $MAINPID
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=default.target
+`
+
+// installUserService implements "divoom-daemon install-user-service": it
+// writes a systemd user unit running this binary. Arguments after the flags
+// are added to the daemon's command line.
+func installUserService(args []string) error {
+	fs := flag.NewFlagSet("install-user-service", flag.ContinueOnError)
+	force := fs.Bool("force", false, "Overwrite an existing unit")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: divoom-daemon install-user-service [--force] [-- daemon flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot find the daemon binary: %v", err)
+	}
+	configHome, err := os.UserConfigDir()
+	if err != nil {
+		return err
+	}
+	unitDir := filepath.Join(configHome, "systemd", "user")
+	unitPath := filepath.Join(unitDir, userUnitName)
+	if _, err := os.Stat(unitPath); err == nil && !*force {
+		return fmt.Errorf("%s exists, use --force to overwrite it", unitPath)
+	}
+
+	command := []string{systemdQuote(executable), "--pause-when-locked", "--api-addr=unix:%t/divoom-monitor.sock"}
+	for _, arg := range fs.Args() {
+		command = append(command, systemdQuote(arg))
+	}
+	if err := os.MkdirAll(unitDir, 0755); err != nil {
+		return err
+	}
+	unit := fmt.Sprintf(userUnitTemplate, strings.Join(command, " "))
+	if err := os.WriteFile(unitPath, []byte(unit), 0644); err != nil {
+		return err
+	}
+
+	configDir, err := userConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s\n", unitPath)
+	fmt.Printf("Configuration is read from %s\n", filepath.Join(configDir, "config.json"))
+	fmt.Println("\nEnable and start it with:")
+	fmt.Println("  systemctl --user daemon-reload")
+	fmt.Println("  systemctl --user enable --now divoom-monitor")
+	fmt.Println("\nControl it with:")
+	fmt.Println("  divoom-ctl --addr unix:$XDG_RUNTIME_DIR/divoom-monitor.sock status")
+	return nil
+}
+
+// systemdQuote escapes an argument for ExecStart, where % starts a
+// specifier, $ a variable and whitespace separates arguments.
+func systemdQuote(arg string) string {
+	arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
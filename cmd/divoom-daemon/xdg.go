@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+const appDirName = "divoom-monitor"
+
+// userConfigDir is $XDG_CONFIG_HOME/divoom-monitor, by default
+// ~/.config/divoom-monitor.
+func userConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appDirName), nil
+}
+
+// defaultConfigFile returns config.json in the user config directory, or ""
+// if there is none.
+func defaultConfigFile() string {
+	dir, err := userConfigDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(dir, "config.json")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// stateDir is where the daemon keeps its state: $STATE_DIRECTORY when run by
+// a unit with StateDirectory=, else $XDG_STATE_HOME/divoom-monitor, by
+// default ~/.local/state/divoom-monitor.
+func stateDir() (string, error) {
+	// systemd may pass several directories separated by colons
+	if dir := os.Getenv("STATE_DIRECTORY"); dir != "" {
+		return filepath.SplitList(dir)[0], nil
+	}
+	// Relative paths are invalid and must be ignored, as the spec says
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appDirName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", appDirName), nil
+}
+
+// SavedState is what the daemon restores after a restart: the target chosen
+// through the control API or discovery, whether updates were paused and the
+// brightness from before a dim schedule rule that is still in effect.
+type SavedState struct {
+	Device     DaemonDevice `json:"Device"`
+	LcdId      int          `json:"LcdId"`
+	Paused     bool         `json:"Paused"`
+	DimRestore int          `json:"DimRestore,omitempty"`
+}
+
+// StateFile stores SavedState as JSON, writing only when it has changed.
+type StateFile struct {
+	path string
+	last SavedState
+}
+
+// openStateFile creates the state directory and reads the previous state,
+// if any.
+func openStateFile() (*StateFile, *SavedState, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create state directory: %v", err)
+	}
+	f := &StateFile{path: filepath.Join(dir, "state.json")}
+
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return f, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(data, &f.last); err != nil {
+		return nil, nil, fmt.Errorf("parse %s: %v", f.path, err)
+	}
+	saved := f.last
+	return f, &saved, nil
+}
+
+// Save writes state unless it equals what was written last. The file is
+// replaced by a rename, so a crash never leaves it half written.
+func (f *StateFile) Save(state SavedState) error {
+	if f == nil || reflect.DeepEqual(state, f.last) {
+		return nil
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return err
+	}
+	f.last = state
+	return nil
+}
+
+// sameDevice compares by device ID, or by IP address if either has no ID,
+// as devices given with --device do not.
+func sameDevice(a, b DaemonDevice) bool {
+	if a.DeviceId != 0 && b.DeviceId != 0 {
+		return a.DeviceId == b.DeviceId
+	}
+	return a.DevicePrivateIP == b.DevicePrivateIP
+}
+
+// Saved returns the part of the daemon state that is kept across restarts.
+func (s *DaemonState) Saved() SavedState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SavedState{Device: s.device, LcdId: s.lcdId, Paused: s.paused, DimRestore: s.dimRestore}
+}
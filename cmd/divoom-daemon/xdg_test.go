@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStateDir(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+	t.Setenv("STATE_DIRECTORY", "")
+	t.Setenv("XDG_STATE_HOME", "")
+	tests := []struct {
+		stateDirectory, xdgStateHome, want string
+	}{
+		{"", "", "/home/user/.local/state/divoom-monitor"},
+		{"", "/xdg/state", "/xdg/state/divoom-monitor"},
+		{"", "relative", "/home/user/.local/state/divoom-monitor"},
+		{"/var/lib/divoom-monitor", "/xdg/state", "/var/lib/divoom-monitor"},
+	}
+	for _, test := range tests {
+		t.Setenv("STATE_DIRECTORY", test.stateDirectory)
+		t.Setenv("XDG_STATE_HOME", test.xdgStateHome)
+		got, err := stateDir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("STATE_DIRECTORY=%q XDG_STATE_HOME=%q: got %s, want %s", test.stateDirectory, test.xdgStateHome, got, test.want)
+		}
+	}
+}
+
+func TestStateFile(t *testing.T) {
+	t.Setenv("STATE_DIRECTORY", "")
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	f, saved, err := openStateFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved != nil {
+		t.Fatalf("got state %+v before the first save", saved)
+	}
+	state := SavedState{Device: DaemonDevice{DeviceId: 7, DevicePrivateIP: "10.0.0.7"}, LcdId: 2, Paused: true}
+	if err := f.Save(state); err != nil {
+		t.Fatal(err)
+	}
+
+	// An unchanged state is not written again
+	if err := os.Remove(f.path); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Save(state); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(f.path); !os.IsNotExist(err) {
+		t.Fatalf("unchanged state was written again")
+	}
+	state.LcdId = 3
+	if err := f.Save(state); err != nil {
+		t.Fatal(err)
+	}
+
+	_, saved, err = openStateFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved == nil || *saved != state {
+		t.Errorf("got %+v, want %+v", saved, state)
+	}
+	if dir := filepath.Dir(f.path); filepath.Base(dir) != "divoom-monitor" {
+		t.Errorf("state file in %s", dir)
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	tests := map[string]string{
+		"--interval=3":         "--interval=3",
+		"/opt/my tools/daemon": `"/opt/my tools/daemon"`,
+		"100%":                 "100%%",
+		"$HOME":                "$$HOME",
+		`say "hi"`:             `"say \"hi\""`,
+		"":                     `""`,
+	}
+	for arg, want := range tests {
+		if got := systemdQuote(arg); got != want {
+			t.Errorf("systemdQuote(%q) = %s, want %s", arg, got, want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"divoom-monitor/internal/fakedevice"
+)
+
+var version = "dev" // Set by build flags
+
+func main() {
+	var showVersion = flag.Bool("version", false, "Show version information")
+	var addr = flag.String("addr", "127.0.0.1:8080", "Address to listen on")
+	var name = flag.String("name", "Fake TimeGate", "Device name reported by discovery")
+	var ip = flag.String("ip", "127.0.0.1", "Device IP reported by discovery")
+	var hardware = flag.Int("hardware", 400, "Hardware code reported by discovery")
+	var latency = flag.Duration("latency", 0, "Delay every response by this long")
+	var status = flag.Int("status", 0, "Answer /post with this HTTP status instead of 200")
+	var errorCode = flag.Int("error-code", 0, "Answer /post with this non-zero error_code")
+	var quiet = flag.Bool("quiet", false, "Do not log received commands")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("divoom-fakedevice version %s\n", version)
+		return
+	}
+
+	device := fakedevice.New(fakedevice.DeviceInfo{
+		DeviceName:      *name,
+		DeviceId:        300000001,
+		DevicePrivateIP: *ip,
+		DeviceMac:       "a1b2c3d4e5f6",
+		Hardware:        *hardware,
+	})
+	device.SetLatency(*latency)
+	device.SetHTTPStatus(*status)
+	device.SetErrorCode(*errorCode)
+
+	if !*quiet {
+		device.OnCommand(func(c fakedevice.Command) {
+			log.Printf("%s: %s", c.Name, c.Raw)
+		})
+	}
+
+	log.Printf("Fake Divoom device %q listening on http://%s", *name, *addr)
+	log.Printf("  device endpoint:    http://%s/post", *addr)
+	log.Printf("  discovery endpoint: http://%s/Device/ReturnSameLANDevice", *addr)
+	log.Fatal(http.ListenAndServe(*addr, device.Handler()))
+}
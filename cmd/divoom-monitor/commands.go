@@ -0,0 +1,351 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"divoom-monitor/internal/divoom"
+)
+
+// Exit codes of the subcommands.
+const (
+	exitError    = 1 // the device or the discovery service returned an error
+	exitUsage    = 2 // invalid command line
+	exitNoDevice = 3 // no device found, or several and no --device given
+)
+
+// usageError is returned for an invalid command line.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+var errNoDevice = errors.New("no devices found")
+
+// exitCode maps the error of a subcommand to the exit status.
+func exitCode(err error) int {
+	var usage usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, errNoDevice):
+		return exitNoDevice
+	default:
+		return exitError
+	}
+}
+
+// deviceFlags are the flags shared by the commands that talk to a device.
+type deviceFlags struct {
+	fs     *flag.FlagSet
+	device *string
+}
+
+func newCommandFlags(name string) *deviceFlags {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	return &deviceFlags{
+		fs:     fs,
+		device: fs.String("device", "", "Device IP address (default: the only device found)"),
+	}
+}
+
+func (f *deviceFlags) parse(args []string) error {
+	if err := f.fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	return nil
+}
+
+// resolve returns the --device, or the device found by discovery when
+// there is exactly one.
+func (f *deviceFlags) resolve() (DivoomDevice, error) {
+	if *f.device != "" {
+		return DivoomDevice{DeviceName: *f.device, DevicePrivateIP: *f.device}, nil
+	}
+	devices, err := findDevices()
+	if err != nil {
+		return DivoomDevice{}, err
+	}
+	switch len(devices) {
+	case 0:
+		return DivoomDevice{}, errNoDevice
+	case 1:
+		return devices[0], nil
+	default:
+		return DivoomDevice{}, fmt.Errorf("%w: %d devices found, choose one with --device", errNoDevice, len(devices))
+	}
+}
+
+func runCommand(command string, args []string) error {
+	switch command {
+	case "scan":
+		return runScan(args)
+	case "send":
+		return runSend(args)
+	case "watch":
+		return runWatch(args)
+	case "text":
+		return runText(args)
+	case "brightness":
+		return runBrightness(args)
+	case "info":
+		return runInfo(args)
+	default:
+		return usageError(fmt.Sprintf("unknown command %q; see 'divoom-monitor --help'", command))
+	}
+}
+
+func runScan(args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the devices as JSON")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+
+	devices, err := findDevices()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		entries := make([]scanEntry, len(devices))
+		for i, d := range devices {
+			entries[i] = scanEntry{DivoomDevice: d, Model: divoom.ModelName(d.Hardware, d.DeviceName)}
+		}
+		// An empty list is still valid JSON; the exit status tells it apart
+		if err := printJSON(entries); err != nil {
+			return err
+		}
+		if len(devices) == 0 {
+			return errNoDevice
+		}
+		return nil
+	}
+	if len(devices) == 0 {
+		return errNoDevice
+	}
+	fmt.Printf("Found %d device(s):\n", len(devices))
+	for i, d := range devices {
+		fmt.Printf("  %d. %s (%s) %s\n", i+1, d.DeviceName, d.DevicePrivateIP, divoom.ModelName(d.Hardware, d.DeviceName))
+	}
+	return nil
+}
+
+// scanEntry is a device in the output of scan --json.
+type scanEntry struct {
+	DivoomDevice
+	Model string `json:"Model"`
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func parseLCD(fs *flag.FlagSet) *int {
+	return fs.Int("lcd", 0, "LCD ID to update (0-4, TimeGate only)")
+}
+
+// checkLCD validates the LCD ID against the device model. Devices of an
+// unknown model, e.g. given with --device, accept 0-4.
+func checkLCD(device DivoomDevice, lcd int) error {
+	if model, ok := divoom.LookupModel(device.Hardware, device.DeviceName); ok {
+		if err := model.CheckLCD(lcd); err != nil {
+			return usageError(err.Error())
+		}
+		return nil
+	}
+	if lcd < 0 || lcd > 4 {
+		return usageError(fmt.Sprintf("invalid LCD %d, must be between 0 and 4", lcd))
+	}
+	return nil
+}
+
+// runSend samples the hardware once and sends it.
+func runSend(args []string) error {
+	flags := newCommandFlags("send")
+	lcd := parseLCD(flags.fs)
+	if err := flags.parse(args); err != nil {
+		return err
+	}
+	device, err := flags.resolve()
+	if err != nil {
+		return err
+	}
+	if err := checkLCD(device, *lcd); err != nil {
+		return err
+	}
+
+	data := getHardwareData()
+	if err := sendDataToDevice(device, *lcd, data); err != nil {
+		return fmt.Errorf("%s: %v", device.DevicePrivateIP, err)
+	}
+	fmt.Printf("Sent to %s (%s): %s\n", device.DeviceName, device.DevicePrivateIP, formatHardwareData(data))
+	return nil
+}
+
+// runWatch sends until interrupted, or --count times. Failed sends are
+// reported and retried; the exit status is that of the last send.
+func runWatch(args []string) error {
+	flags := newCommandFlags("watch")
+	lcd := parseLCD(flags.fs)
+	interval := flags.fs.Duration("interval", 2*time.Second, "Time between updates")
+	count := flags.fs.Int("count", 0, "Stop after this many updates (0 for no limit)")
+	if err := flags.parse(args); err != nil {
+		return err
+	}
+	if *interval < time.Second {
+		return usageError("--interval must be at least 1s")
+	}
+	device, err := flags.resolve()
+	if err != nil {
+		return err
+	}
+	if err := checkLCD(device, *lcd); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for sent := 0; *count == 0 || sent < *count; sent++ {
+		if sent > 0 {
+			select {
+			case <-ticker.C:
+			case <-signals:
+				return lastErr
+			}
+		}
+		data := getHardwareData()
+		now := time.Now().Format("15:04:05")
+		if lastErr = sendDataToDevice(device, *lcd, data); lastErr != nil {
+			lastErr = fmt.Errorf("%s: %v", device.DevicePrivateIP, lastErr)
+			fmt.Fprintf(os.Stderr, "%s Error: %v\n", now, lastErr)
+			continue
+		}
+		fmt.Printf("%s %s\n", now, formatHardwareData(data))
+	}
+	return lastErr
+}
+
+func runText(args []string) error {
+	flags := newCommandFlags("text")
+	color := flags.fs.String("color", "#FFFFFF", "Text color (#RRGGBB)")
+	if err := flags.parse(args); err != nil {
+		return err
+	}
+	if flags.fs.NArg() == 0 {
+		return usageError("usage: divoom-monitor text [--device IP] [--color #RRGGBB] <message>")
+	}
+	device, err := flags.resolve()
+	if err != nil {
+		return err
+	}
+
+	payload := TextPayload{
+		Command:    "Draw/SendHttpText",
+		TextId:     1,
+		Font:       1,
+		TextWidth:  64,
+		Speed:      100,
+		TextString: strings.Join(flags.fs.Args(), " "),
+		Color:      *color,
+		Align:      1,
+	}
+	return deviceClient().Command(device.DevicePrivateIP, payload, nil)
+}
+
+func runBrightness(args []string) error {
+	flags := newCommandFlags("brightness")
+	if err := flags.parse(args); err != nil {
+		return err
+	}
+	if flags.fs.NArg() != 1 {
+		return usageError("usage: divoom-monitor brightness [--device IP] <0-100>")
+	}
+	brightness, err := strconv.Atoi(flags.fs.Arg(0))
+	if err != nil || brightness < 0 || brightness > 100 {
+		return usageError(fmt.Sprintf("invalid brightness %q, must be between 0 and 100", flags.fs.Arg(0)))
+	}
+	device, err := flags.resolve()
+	if err != nil {
+		return err
+	}
+	return deviceClient().SetBrightness(device.DevicePrivateIP, brightness)
+}
+
+func runInfo(args []string) error {
+	flags := newCommandFlags("info")
+	if err := flags.parse(args); err != nil {
+		return err
+	}
+	device, err := flags.resolve()
+	if err != nil {
+		return err
+	}
+
+	client := deviceClient()
+	conf, err := client.GetAllConf(device.DevicePrivateIP)
+	if err != nil {
+		return err
+	}
+	channel, err := client.GetChannel(device.DevicePrivateIP)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Device:     %s (%s)\n", device.DeviceName, device.DevicePrivateIP)
+	if model, ok := divoom.LookupModel(device.Hardware, device.DeviceName); ok {
+		fmt.Printf("Model:      %s (%s, %d LCD)\n", model.Name, resolution(model), model.LCDs)
+	} else if device.Hardware != 0 {
+		fmt.Printf("Model:      %s\n", divoom.ModelName(device.Hardware, device.DeviceName))
+	}
+	screen := "on"
+	if conf.LightSwitch == 0 {
+		screen = "off"
+	}
+	fmt.Printf("Screen:     %s\n", screen)
+	fmt.Printf("Brightness: %d\n", conf.Brightness)
+	fmt.Printf("Channel:    %s\n", channelName(channel))
+	fmt.Printf("Clock:      %d\n", conf.CurClockId)
+	return nil
+}
+
+func resolution(model divoom.Model) string {
+	if model.Size == 0 {
+		return "no pixel display"
+	}
+	return fmt.Sprintf("%dx%d", model.Size, model.Size)
+}
+
+func channelName(index int) string {
+	for name, i := range divoom.ChannelNames {
+		if i == index {
+			return name
+		}
+	}
+	return strconv.Itoa(index)
+}
+
+func deviceClient() *divoom.Client {
+	return &divoom.Client{Endpoints: endpoints, HTTP: httpClient}
+}
+
+func formatHardwareData(data HardwareData) string {
+	return fmt.Sprintf("CPU %d%% %d°C, GPU %d%% %d°C, Memory %d%%, Disk %d°C",
+		data.CpuUsage, data.CpuTemp, data.GpuUsage, data.GpuTemp, data.MemoryUsage, data.DiskTemp)
+}
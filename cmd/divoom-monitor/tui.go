@@ -0,0 +1,387 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"divoom-monitor/internal/divoom"
+
+	"golang.org/x/term"
+)
+
+// tuiSample is the result of one monitoring cycle.
+type tuiSample struct {
+	data    HardwareData
+	sent    bool
+	err     error
+	latency time.Duration
+	at      time.Time
+}
+
+type tuiScan struct {
+	devices []DivoomDevice
+	err     error
+}
+
+// TUI is the interactive terminal interface. The target and pause state are
+// shared with the monitoring goroutine; everything else belongs to Run.
+type TUI struct {
+	mu     sync.Mutex
+	device *DivoomDevice
+	lcd    int
+	paused bool
+
+	devices  []DivoomDevice
+	cursor   int
+	scanning bool
+	scanErr  error
+	last     *tuiSample
+	message  string
+}
+
+func newTUI() *TUI {
+	return &TUI{}
+}
+
+func (t *TUI) target() (*DivoomDevice, int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.device, t.lcd, t.paused
+}
+
+// Run takes over the terminal until the user quits.
+func (t *TUI) Run() error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("the interactive monitor needs a terminal; see 'divoom-monitor --help'")
+	}
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	// Alternate screen, hidden cursor; restored on exit
+	fmt.Print("\033[?1049h\033[?25l")
+	defer func() {
+		fmt.Print("\033[?25h\033[?1049l")
+		term.Restore(fd, oldState)
+	}()
+
+	keys := make(chan byte, 16)
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			for _, b := range buf[:n] {
+				keys <- b
+			}
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	samples := make(chan tuiSample)
+	stop := make(chan struct{})
+	defer close(stop)
+	go t.monitor(samples, stop)
+
+	scans := make(chan tuiScan, 1)
+	t.startScan(scans)
+
+	redraw := time.NewTicker(time.Second)
+	defer redraw.Stop()
+
+	esc := 0
+	for {
+		t.render()
+		select {
+		case b, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			// Arrow keys arrive as ESC [ A and ESC [ B
+			switch {
+			case esc == 0 && b == 0x1b:
+				esc = 1
+				continue
+			case esc == 1 && b == '[':
+				esc = 2
+				continue
+			case esc == 2:
+				esc = 0
+				switch b {
+				case 'A':
+					t.moveCursor(-1)
+				case 'B':
+					t.moveCursor(1)
+				}
+				continue
+			}
+			esc = 0
+			if t.handleKey(b, scans) {
+				return nil
+			}
+		case scan := <-scans:
+			t.finishScan(scan)
+		case sample := <-samples:
+			t.last = &sample
+		case <-signals:
+			return nil
+		case <-redraw.C:
+		}
+	}
+}
+
+// handleKey acts on one key press and reports whether to quit.
+func (t *TUI) handleKey(b byte, scans chan tuiScan) bool {
+	switch b {
+	case 'q', 'Q', 3: // 3 is Ctrl-C in raw mode
+		return true
+	case 'k':
+		t.moveCursor(-1)
+	case 'j':
+		t.moveCursor(1)
+	case '\r', '\n':
+		if t.cursor < len(t.devices) {
+			t.selectDevice(t.devices[t.cursor])
+		}
+	case 'p', 'P', ' ':
+		t.mu.Lock()
+		t.paused = !t.paused
+		paused := t.paused
+		t.mu.Unlock()
+		if paused {
+			t.message = "Updates paused"
+		} else {
+			t.message = "Updates resumed"
+		}
+	case 'l', 'L':
+		device, lcd, _ := t.target()
+		t.setLCD((lcd + 1) % lcdCount(device))
+	case '0', '1', '2', '3', '4':
+		device, _, _ := t.target()
+		if lcd := int(b - '0'); lcd < lcdCount(device) {
+			t.setLCD(lcd)
+		} else {
+			t.message = fmt.Sprintf("The device has %d LCD(s)", lcdCount(device))
+		}
+	case 'r', 'R':
+		t.startScan(scans)
+	}
+	return false
+}
+
+func (t *TUI) setLCD(lcd int) {
+	t.mu.Lock()
+	t.lcd = lcd
+	t.mu.Unlock()
+	t.message = fmt.Sprintf("Sending to LCD %d", lcd)
+}
+
+// lcdCount returns the number of LCDs of device: from its model, 5 when
+// the model is unknown and 1 when no device is selected.
+func lcdCount(device *DivoomDevice) int {
+	if device == nil {
+		return 1
+	}
+	if model, ok := divoom.LookupModel(device.Hardware, device.DeviceName); ok {
+		return model.LCDs
+	}
+	return 5
+}
+
+func (t *TUI) moveCursor(delta int) {
+	if len(t.devices) == 0 {
+		return
+	}
+	t.cursor = (t.cursor + delta + len(t.devices)) % len(t.devices)
+}
+
+func (t *TUI) selectDevice(device DivoomDevice) {
+	t.mu.Lock()
+	t.device = &device
+	if t.lcd >= lcdCount(&device) {
+		t.lcd = 0
+	}
+	t.mu.Unlock()
+	t.message = fmt.Sprintf("Monitoring %s (%s)", device.DeviceName, device.DevicePrivateIP)
+}
+
+func (t *TUI) startScan(scans chan tuiScan) {
+	if t.scanning {
+		return
+	}
+	t.scanning = true
+	go func() {
+		devices, err := findDevices()
+		scans <- tuiScan{devices: devices, err: err}
+	}()
+}
+
+func (t *TUI) finishScan(scan tuiScan) {
+	t.scanning = false
+	t.scanErr = scan.err
+	if scan.err != nil {
+		return
+	}
+	t.devices = scan.devices
+	if t.cursor >= len(t.devices) {
+		t.cursor = 0
+	}
+	// With a single device there is nothing to choose
+	if device, _, _ := t.target(); device == nil && len(t.devices) == 1 {
+		t.selectDevice(t.devices[0])
+	}
+}
+
+// monitor samples the hardware every two seconds and sends the data to the
+// selected device unless paused.
+func (t *TUI) monitor(samples chan<- tuiSample, stop <-chan struct{}) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+
+		sample := tuiSample{data: getHardwareData(), at: time.Now()}
+		if device, lcd, paused := t.target(); device != nil && !paused {
+			start := time.Now()
+			sample.err = sendDataToDevice(*device, lcd, sample.data)
+			sample.sent = true
+			sample.latency = time.Since(start)
+		}
+
+		select {
+		case samples <- sample:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// render redraws the whole screen. Lines end in \r\n because the terminal
+// is in raw mode.
+func (t *TUI) render() {
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width < 40 {
+		width = 80
+	}
+	device, lcd, paused := t.target()
+
+	// Lines are cut to the width before any escape sequence is added, so a
+	// long line never loses its closing reset
+	var lines []string
+	fit := func(format string, args ...interface{}) string {
+		line := fmt.Sprintf(format, args...)
+		if len([]rune(line)) > width {
+			line = string([]rune(line)[:width])
+		}
+		return line
+	}
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fit(format, args...))
+	}
+	addStyled := func(style, format string, args ...interface{}) {
+		lines = append(lines, style+fit(format, args...)+"\033[0m")
+	}
+
+	state := "running"
+	if paused {
+		state = "paused"
+	} else if device == nil {
+		state = "no device selected"
+	}
+	addStyled(styleBold, "Divoom PC Monitor %s  [%s]", version, state)
+	add(strings.Repeat("─", min(width, 60)))
+
+	addStyled(styleBold, "Devices")
+	switch {
+	case t.scanning:
+		add("  Scanning...")
+	case t.scanErr != nil:
+		add("  Scan failed: %v", t.scanErr)
+	case len(t.devices) == 0:
+		add("  No devices found (r to rescan)")
+	}
+	for i, d := range t.devices {
+		marker := "  "
+		if i == t.cursor {
+			marker = "> "
+		}
+		active := ""
+		if device != nil && device.DevicePrivateIP == d.DevicePrivateIP {
+			active = " *"
+			if lcdCount(&d) > 1 {
+				active = fmt.Sprintf(" * LCD %d", lcd)
+			}
+		}
+		add("%s%d. %s (%s, %s)%s", marker, i+1, d.DeviceName, d.DevicePrivateIP,
+			divoom.ModelName(d.Hardware, d.DeviceName), active)
+	}
+	add("")
+
+	addStyled(styleBold, "Metrics")
+	if t.last == nil {
+		add("  Waiting for the first sample...")
+	} else {
+		data := t.last.data
+		add("  CPU     %3d%% %s %3d°C", data.CpuUsage, meter(data.CpuUsage), data.CpuTemp)
+		add("  GPU     %3d%% %s %3d°C", data.GpuUsage, meter(data.GpuUsage), data.GpuTemp)
+		add("  Memory  %3d%% %s", data.MemoryUsage, meter(data.MemoryUsage))
+		add("  Disk         %s %3d°C", strings.Repeat(" ", 20), data.DiskTemp)
+	}
+	add("")
+
+	addStyled(styleBold, "Status")
+	switch {
+	case t.last == nil || !t.last.sent:
+		add("  Nothing sent yet")
+	case t.last.err != nil:
+		addStyled(styleRed, "  Send failed at %s: %v", t.last.at.Format("15:04:05"), t.last.err)
+	default:
+		add("  Sent at %s (%s)", t.last.at.Format("15:04:05"), t.last.latency.Round(time.Millisecond))
+	}
+	if t.last != nil && t.last.data.GpuStatus != "" {
+		add("  GPU: %s", t.last.data.GpuStatus)
+	}
+	if t.message != "" {
+		add("  %s", t.message)
+	}
+	add("")
+	addStyled(styleDim, "↑/↓ select  Enter monitor  p pause  l/0-4 LCD  r rescan  q quit")
+
+	var b strings.Builder
+	b.WriteString("\033[H")
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\033[K\r\n")
+	}
+	b.WriteString("\033[J")
+	os.Stdout.WriteString(b.String())
+}
+
+// meter draws a 20 character bar for a percentage.
+// Text styles for render
+const (
+	styleBold = "\033[1m"
+	styleDim  = "\033[2m"
+	styleRed  = "\033[31m"
+)
+
+func meter(percent int) string {
+	n := max(0, min(20, percent/5))
+	return strings.Repeat("█", n) + strings.Repeat("░", 20-n)
+}
@@ -1,112 +1,157 @@
-package main
-
-import (
-	"fmt"
-	"strings"
-	"time"
-
-	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/host"
-	"github.com/shirou/gopsutil/v3/mem"
-)
-
-type TestHardwareData struct {
-	CpuUsage    int
-	GpuUsage    int
-	CpuTemp     int
-	GpuTemp     int
-	MemoryUsage int
-	DiskTemp    int
-}
-
-func main() {
-	fmt.Println("Testing Hardware Data Collection")
-	fmt.Println("===============================")
-
-	data := getTestHardwareData()
-	
-	fmt.Printf("CPU Usage: %d%%\n", data.CpuUsage)
-	fmt.Printf("CPU Temp: %d°C\n", data.CpuTemp)
-	fmt.Printf("GPU Usage: %d%%\n", data.GpuUsage)
-	fmt.Printf("GPU Temp: %d°C\n", data.GpuTemp)
-	fmt.Printf("Memory Usage: %d%%\n", data.MemoryUsage)
-	fmt.Printf("Disk Temp: %d°C\n", data.DiskTemp)
-
-	// Format as text for Divoom
-	textData := fmt.Sprintf("CPU:%d%% %d°C GPU:%d%% %d°C MEM:%d%% DSK:%d°C",
-		data.CpuUsage, data.CpuTemp,
-		data.GpuUsage, data.GpuTemp,
-		data.MemoryUsage, data.DiskTemp)
-	
-	fmt.Printf("\nFormatted text: %s\n", textData)
-	fmt.Printf("Text length: %d chars\n", len(textData))
-}
-
-func getTestHardwareData() TestHardwareData {
-	data := TestHardwareData{}
-
-	fmt.Println("\nCollecting hardware data...")
-
-	// CPU Usage
-	fmt.Print("Getting CPU usage... ")
-	cpuPercent, err := cpu.Percent(time.Second, false)
-	if err == nil && len(cpuPercent) > 0 {
-		data.CpuUsage = int(cpuPercent[0])
-		fmt.Printf("OK (%d%%)\n", data.CpuUsage)
-	} else {
-		fmt.Printf("ERROR: %v\n", err)
-	}
-
-	// Get all temperature sensors
-	fmt.Print("Getting temperature sensors... ")
-	temps, err := host.SensorsTemperatures()
-	if err == nil {
-		fmt.Printf("OK (found %d sensors)\n", len(temps))
-		
-		fmt.Println("Available sensors:")
-		for _, temp := range temps {
-			fmt.Printf("  %s: %.1f°C\n", temp.SensorKey, temp.Temperature)
-		}
-
-		// CPU Temperature
-		for _, temp := range temps {
-			if strings.Contains(strings.ToLower(temp.SensorKey), "cpu") || 
-			   strings.Contains(strings.ToLower(temp.SensorKey), "package") ||
-			   strings.Contains(strings.ToLower(temp.SensorKey), "core") {
-				data.CpuTemp = int(temp.Temperature)
-				fmt.Printf("Using CPU temp from %s: %d°C\n", temp.SensorKey, data.CpuTemp)
-				break
-			}
-		}
-
-		// Disk Temperature
-		for _, temp := range temps {
-			if strings.Contains(strings.ToLower(temp.SensorKey), "nvme") || 
-			   strings.Contains(strings.ToLower(temp.SensorKey), "sda") ||
-			   strings.Contains(strings.ToLower(temp.SensorKey), "disk") {
-				data.DiskTemp = int(temp.Temperature)
-				fmt.Printf("Using disk temp from %s: %d°C\n", temp.SensorKey, data.DiskTemp)
-				break
-			}
-		}
-	} else {
-		fmt.Printf("ERROR: %v\n", err)
-	}
-
-	// Memory Usage
-	fmt.Print("Getting memory usage... ")
-	vmStat, err := mem.VirtualMemory()
-	if err == nil {
-		data.MemoryUsage = int(vmStat.UsedPercent)
-		fmt.Printf("OK (%d%%)\n", data.MemoryUsage)
-	} else {
-		fmt.Printf("ERROR: %v\n", err)
-	}
-
-	// GPU data (placeholder)
-	data.GpuUsage = 0
-	data.GpuTemp = 0
-	fmt.Println("GPU data: Not implemented (would need nvidia-smi parsing)")
-
-	return data
-}
\ No newline at end of file
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+type TestHardwareData struct {
+	CpuUsage    int
+	GpuUsage    int
+	CpuTemp     int
+	GpuTemp     int
+	MemoryUsage int
+	DiskTemp    int
+
+	CpuTempSensor  string       `json:",omitempty"`
+	DiskTempSensor string       `json:",omitempty"`
+	Sensors        []TestSensor // every temperature sensor found
+	Errors         []string     `json:",omitempty"`
+}
+
+type TestSensor struct {
+	Key         string
+	Temperature float64
+	High        float64 `json:",omitempty"`
+	Critical    float64 `json:",omitempty"`
+}
+
+// progress receives the step by step output; it is discarded with --json.
+var progress io.Writer = os.Stdout
+
+func main() {
+	var asJSON = flag.Bool("json", false, "Print the collected data and all sensors as JSON")
+	flag.Parse()
+
+	if *asJSON {
+		progress = io.Discard
+		data := getTestHardwareData()
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(data); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Println("Testing Hardware Data Collection")
+	fmt.Println("===============================")
+
+	data := getTestHardwareData()
+
+	fmt.Printf("CPU Usage: %d%%\n", data.CpuUsage)
+	fmt.Printf("CPU Temp: %d°C\n", data.CpuTemp)
+	fmt.Printf("GPU Usage: %d%%\n", data.GpuUsage)
+	fmt.Printf("GPU Temp: %d°C\n", data.GpuTemp)
+	fmt.Printf("Memory Usage: %d%%\n", data.MemoryUsage)
+	fmt.Printf("Disk Temp: %d°C\n", data.DiskTemp)
+
+	// Format as text for Divoom
+	textData := fmt.Sprintf("CPU:%d%% %d°C GPU:%d%% %d°C MEM:%d%% DSK:%d°C",
+		data.CpuUsage, data.CpuTemp,
+		data.GpuUsage, data.GpuTemp,
+		data.MemoryUsage, data.DiskTemp)
+
+	fmt.Printf("\nFormatted text: %s\n", textData)
+	fmt.Printf("Text length: %d chars\n", len(textData))
+}
+
+func getTestHardwareData() TestHardwareData {
+	data := TestHardwareData{Sensors: []TestSensor{}}
+
+	fmt.Fprintln(progress, "\nCollecting hardware data...")
+
+	// CPU Usage
+	fmt.Fprint(progress, "Getting CPU usage... ")
+	cpuPercent, err := cpu.Percent(time.Second, false)
+	if err == nil && len(cpuPercent) > 0 {
+		data.CpuUsage = int(cpuPercent[0])
+		fmt.Fprintf(progress, "OK (%d%%)\n", data.CpuUsage)
+	} else {
+		fmt.Fprintf(progress, "ERROR: %v\n", err)
+		data.Errors = append(data.Errors, fmt.Sprintf("cpu usage: %v", err))
+	}
+
+	// Get all temperature sensors
+	fmt.Fprint(progress, "Getting temperature sensors... ")
+	temps, err := host.SensorsTemperatures()
+	if err == nil {
+		fmt.Fprintf(progress, "OK (found %d sensors)\n", len(temps))
+
+		fmt.Fprintln(progress, "Available sensors:")
+		for _, temp := range temps {
+			fmt.Fprintf(progress, "  %s: %.1f°C\n", temp.SensorKey, temp.Temperature)
+			data.Sensors = append(data.Sensors, TestSensor{
+				Key:         temp.SensorKey,
+				Temperature: temp.Temperature,
+				High:        temp.High,
+				Critical:    temp.Critical,
+			})
+		}
+
+		// CPU Temperature
+		for _, temp := range temps {
+			if strings.Contains(strings.ToLower(temp.SensorKey), "cpu") ||
+				strings.Contains(strings.ToLower(temp.SensorKey), "package") ||
+				strings.Contains(strings.ToLower(temp.SensorKey), "core") {
+				data.CpuTemp = int(temp.Temperature)
+				data.CpuTempSensor = temp.SensorKey
+				fmt.Fprintf(progress, "Using CPU temp from %s: %d°C\n", temp.SensorKey, data.CpuTemp)
+				break
+			}
+		}
+
+		// Disk Temperature
+		for _, temp := range temps {
+			if strings.Contains(strings.ToLower(temp.SensorKey), "nvme") ||
+				strings.Contains(strings.ToLower(temp.SensorKey), "sda") ||
+				strings.Contains(strings.ToLower(temp.SensorKey), "disk") {
+				data.DiskTemp = int(temp.Temperature)
+				data.DiskTempSensor = temp.SensorKey
+				fmt.Fprintf(progress, "Using disk temp from %s: %d°C\n", temp.SensorKey, data.DiskTemp)
+				break
+			}
+		}
+	} else {
+		fmt.Fprintf(progress, "ERROR: %v\n", err)
+		data.Errors = append(data.Errors, fmt.Sprintf("temperature sensors: %v", err))
+	}
+
+	// Memory Usage
+	fmt.Fprint(progress, "Getting memory usage... ")
+	vmStat, err := mem.VirtualMemory()
+	if err == nil {
+		data.MemoryUsage = int(vmStat.UsedPercent)
+		fmt.Fprintf(progress, "OK (%d%%)\n", data.MemoryUsage)
+	} else {
+		fmt.Fprintf(progress, "ERROR: %v\n", err)
+		data.Errors = append(data.Errors, fmt.Sprintf("memory usage: %v", err))
+	}
+
+	// GPU data (placeholder)
+	data.GpuUsage = 0
+	data.GpuTemp = 0
+	fmt.Fprintln(progress, "GPU data: Not implemented (would need nvidia-smi parsing)")
+
+	return data
+}
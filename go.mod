@@ -2,7 +2,10 @@ module divoom-monitor
 
 go 1.21
 
-require github.com/shirou/gopsutil/v3 v3.23.12
+require (
+	github.com/shirou/gopsutil/v3 v3.23.12
+	golang.org/x/term v0.15.0
+)
 
 require (
 	github.com/go-ole/go-ole v1.2.6 // indirect
@@ -0,0 +1,173 @@
+package divoom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PCMonitorClockId is the clock face that shows Device/UpdatePCParaInfo data.
+const PCMonitorClockId = 625
+
+// Channels selected with Channel/SetIndex.
+const (
+	ChannelFaces      = 0
+	ChannelCloud      = 1
+	ChannelVisualizer = 2
+	ChannelCustom     = 3
+	ChannelBlack      = 4
+)
+
+// ChannelNames maps the names accepted by ParseChannel to channel indexes.
+var ChannelNames = map[string]int{
+	"faces":      ChannelFaces,
+	"cloud":      ChannelCloud,
+	"visualizer": ChannelVisualizer,
+	"custom":     ChannelCustom,
+	"black":      ChannelBlack,
+}
+
+// ParseChannel accepts a channel index (0-4) or one of ChannelNames.
+func ParseChannel(s string) (int, error) {
+	if index, ok := ChannelNames[strings.ToLower(s)]; ok {
+		return index, nil
+	}
+	index, err := strconv.Atoi(s)
+	if err != nil || index < ChannelFaces || index > ChannelBlack {
+		return 0, fmt.Errorf("invalid channel %q (0-4, faces, cloud, visualizer, custom or black)", s)
+	}
+	return index, nil
+}
+
+// DeviceConf is the device configuration returned by Channel/GetAllConf.
+type DeviceConf struct {
+	Brightness          int `json:"Brightness"`
+	RotationFlag        int `json:"RotationFlag"`
+	ClockTime           int `json:"ClockTime"`
+	GalleryTime         int `json:"GalleryTime"`
+	SingleGalleyTime    int `json:"SingleGalleyTime"`
+	PowerOnChannelId    int `json:"PowerOnChannelId"`
+	GalleryShowTimeFlag int `json:"GalleryShowTimeFlag"`
+	CurClockId          int `json:"CurClockId"`
+	Time24Flag          int `json:"Time24Flag"`
+	TemperatureMode     int `json:"TemperatureMode"`
+	GyrateAngle         int `json:"GyrateAngle"`
+	MirrorFlag          int `json:"MirrorFlag"`
+	LightSwitch         int `json:"LightSwitch"`
+}
+
+// Client sends commands to the HTTP API of devices.
+type Client struct {
+	Endpoints Endpoints
+	HTTP      *http.Client
+}
+
+// NewClient returns a client with a 10 second timeout.
+func NewClient(endpoints Endpoints) *Client {
+	return &Client{Endpoints: endpoints, HTTP: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Command posts payload to the device at ip and, if out is not nil, decodes
+// the response into it. A non-zero error_code is returned as an error.
+func (c *Client) Command(ip string, payload interface{}, out interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.HTTP.Post(c.Endpoints.DeviceURL(ip), "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		ErrorCode json.RawMessage `json:"error_code"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("invalid response: %v", err)
+	}
+	if code := string(result.ErrorCode); code != "" && code != "0" {
+		return fmt.Errorf("device returned error_code %s", code)
+	}
+	if out != nil {
+		return json.Unmarshal(body, out)
+	}
+	return nil
+}
+
+// SetBrightness sets the display brightness (0-100).
+func (c *Client) SetBrightness(ip string, brightness int) error {
+	if brightness < 0 || brightness > 100 {
+		return fmt.Errorf("brightness must be between 0 and 100")
+	}
+	return c.Command(ip, struct {
+		Command    string `json:"Command"`
+		Brightness int    `json:"Brightness"`
+	}{"Channel/SetBrightness", brightness}, nil)
+}
+
+// SetScreen switches the display on or off.
+func (c *Client) SetScreen(ip string, on bool) error {
+	onOff := 0
+	if on {
+		onOff = 1
+	}
+	return c.Command(ip, struct {
+		Command string `json:"Command"`
+		OnOff   int    `json:"OnOff"`
+	}{"Channel/OnOffScreen", onOff}, nil)
+}
+
+// SetChannel selects a channel, one of the Channel constants.
+func (c *Client) SetChannel(ip string, index int) error {
+	return c.Command(ip, struct {
+		Command     string `json:"Command"`
+		SelectIndex int    `json:"SelectIndex"`
+	}{"Channel/SetIndex", index}, nil)
+}
+
+// SetClock selects a clock face, e.g. PCMonitorClockId.
+func (c *Client) SetClock(ip string, clockId int) error {
+	return c.Command(ip, struct {
+		Command string `json:"Command"`
+		ClockId int    `json:"ClockId"`
+	}{"Channel/SetClockSelectId", clockId}, nil)
+}
+
+// GetChannel returns the selected channel, one of the Channel constants.
+func (c *Client) GetChannel(ip string) (int, error) {
+	var result struct {
+		SelectIndex int `json:"SelectIndex"`
+	}
+	if err := c.Command(ip, struct {
+		Command string `json:"Command"`
+	}{"Channel/GetIndex"}, &result); err != nil {
+		return 0, err
+	}
+	return result.SelectIndex, nil
+}
+
+// GetAllConf reads the device configuration.
+func (c *Client) GetAllConf(ip string) (*DeviceConf, error) {
+	conf := &DeviceConf{}
+	if err := c.Command(ip, struct {
+		Command string `json:"Command"`
+	}{"Channel/GetAllConf"}, conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
@@ -0,0 +1,67 @@
+package divoom
+
+import (
+	"testing"
+
+	"divoom-monitor/internal/fakedevice"
+)
+
+func newTestClient(s *fakedevice.Server) *Client {
+	return NewClient(Endpoints{DiscoveryURL: s.DiscoveryURL(), DevicePort: s.Port()})
+}
+
+func TestClientChannelCommands(t *testing.T) {
+	s := fakedevice.NewServer()
+	defer s.Close()
+	client := newTestClient(s)
+
+	if err := client.SetBrightness(s.Host(), 40); err != nil {
+		t.Fatalf("SetBrightness: %v", err)
+	}
+	if err := client.SetScreen(s.Host(), false); err != nil {
+		t.Fatalf("SetScreen: %v", err)
+	}
+	if err := client.SetClock(s.Host(), 12); err != nil {
+		t.Fatalf("SetClock: %v", err)
+	}
+	if err := client.SetChannel(s.Host(), ChannelVisualizer); err != nil {
+		t.Fatalf("SetChannel: %v", err)
+	}
+
+	conf, err := client.GetAllConf(s.Host())
+	if err != nil {
+		t.Fatalf("GetAllConf: %v", err)
+	}
+	if conf.Brightness != 40 || conf.LightSwitch != 0 || conf.CurClockId != 12 {
+		t.Errorf("GetAllConf = %+v", conf)
+	}
+	if state := s.State(); state.SelectIndex != ChannelVisualizer {
+		t.Errorf("SelectIndex = %d, want %d", state.SelectIndex, ChannelVisualizer)
+	}
+}
+
+func TestClientReportsErrorCode(t *testing.T) {
+	s := fakedevice.NewServer()
+	defer s.Close()
+	client := newTestClient(s)
+
+	s.SetErrorCode(1)
+	if err := client.SetClock(s.Host(), PCMonitorClockId); err == nil {
+		t.Errorf("SetClock succeeded despite error_code 1")
+	}
+	if err := client.SetBrightness(s.Host(), 101); err == nil {
+		t.Errorf("SetBrightness accepted 101")
+	}
+}
+
+func TestParseChannel(t *testing.T) {
+	if index, err := ParseChannel("Cloud"); err != nil || index != ChannelCloud {
+		t.Errorf("ParseChannel(Cloud) = %d, %v", index, err)
+	}
+	if index, err := ParseChannel("4"); err != nil || index != ChannelBlack {
+		t.Errorf("ParseChannel(4) = %d, %v", index, err)
+	}
+	if _, err := ParseChannel("5"); err == nil {
+		t.Errorf("ParseChannel accepted 5")
+	}
+}
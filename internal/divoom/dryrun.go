@@ -0,0 +1,59 @@
+package divoom
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DryRunIP is the device address used in dry-run mode when discovery finds
+// no device. It is from a range reserved for documentation.
+const DryRunIP = "192.0.2.1"
+
+// DryRunTransport prints device commands instead of sending them and
+// answers them with error_code 0. Other requests, such as discovery, are
+// passed on to Next.
+type DryRunTransport struct {
+	Out  io.Writer
+	Next http.RoundTripper // default http.DefaultTransport
+
+	mu sync.Mutex
+}
+
+func (t *DryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost {
+		next := t.Next
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		return next.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	t.mu.Lock()
+	fmt.Fprintf(t.Out, "POST %s %s\n", req.URL, body)
+	t.mu.Unlock()
+
+	response := `{"error_code":0}`
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(response)),
+		ContentLength: int64(len(response)),
+		Request:       req,
+	}, nil
+}
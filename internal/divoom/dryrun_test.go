@@ -0,0 +1,36 @@
+package divoom
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"divoom-monitor/internal/fakedevice"
+)
+
+func TestDryRunTransport(t *testing.T) {
+	s := fakedevice.NewServer()
+	defer s.Close()
+
+	var out strings.Builder
+	client := newTestClient(s)
+	client.HTTP = &http.Client{Transport: &DryRunTransport{Out: &out}}
+
+	if err := client.SetBrightness(s.Host(), 20); err != nil {
+		t.Fatalf("SetBrightness: %v", err)
+	}
+	want := "POST " + client.Endpoints.DeviceURL(s.Host()) + ` {"Command":"Channel/SetBrightness","Brightness":20}` + "\n"
+	if out.String() != want {
+		t.Errorf("output %q, want %q", out.String(), want)
+	}
+	if len(s.Commands()) != 0 {
+		t.Errorf("dry run reached the device")
+	}
+
+	// Discovery still goes to the network
+	resp, err := client.HTTP.Get(s.DiscoveryURL())
+	if err != nil {
+		t.Fatalf("discovery: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,105 @@
+// Package divoom holds what the divoom-pcmonitor commands share about
+// talking to Divoom devices.
+package divoom
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+const (
+	// DefaultDiscoveryURL is Divoom's cloud service listing the devices on
+	// the caller's LAN.
+	DefaultDiscoveryURL = "http://app.divoom-gz.com/Device/ReturnSameLANDevice"
+
+	// DefaultDevicePort is the port of the device's HTTP API.
+	DefaultDevicePort = 80
+
+	EnvDiscoveryURL = "DIVOOM_DISCOVERY_URL"
+	EnvDevicePort   = "DIVOOM_DEVICE_PORT"
+)
+
+// Endpoints says where devices are discovered and how they are addressed.
+type Endpoints struct {
+	DiscoveryURL string
+	DevicePort   int
+
+	envErr error // invalid DIVOOM_DEVICE_PORT, reported by Validate
+}
+
+// EndpointsFromEnv returns the defaults, overridden by DIVOOM_DISCOVERY_URL
+// and DIVOOM_DEVICE_PORT when set. A DIVOOM_DEVICE_PORT that is not a number
+// is reported by Validate unless --device-port replaces it.
+func EndpointsFromEnv() Endpoints {
+	e := Endpoints{DiscoveryURL: DefaultDiscoveryURL, DevicePort: DefaultDevicePort}
+	if value := os.Getenv(EnvDiscoveryURL); value != "" {
+		e.DiscoveryURL = value
+	}
+	if value := os.Getenv(EnvDevicePort); value != "" {
+		if port, err := strconv.Atoi(value); err == nil {
+			e.DevicePort = port
+		} else {
+			e.envErr = fmt.Errorf("invalid %s %q", EnvDevicePort, value)
+		}
+	}
+	return e
+}
+
+// RegisterFlags adds --discovery-url and --device-port to fs, defaulting to
+// the current values.
+func (e *Endpoints) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&e.DiscoveryURL, "discovery-url", e.DiscoveryURL,
+		"Device discovery URL (env "+EnvDiscoveryURL+")")
+	fs.Var(&portFlag{e}, "device-port",
+		"Device HTTP API `port` (env "+EnvDevicePort+")")
+}
+
+// portFlag sets DevicePort and drops an invalid DIVOOM_DEVICE_PORT, which
+// the flag overrides.
+type portFlag struct {
+	e *Endpoints
+}
+
+func (f *portFlag) String() string {
+	if f.e == nil {
+		return ""
+	}
+	return strconv.Itoa(f.e.DevicePort)
+}
+
+func (f *portFlag) Set(value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("not a port number")
+	}
+	f.e.DevicePort = port
+	f.e.envErr = nil
+	return nil
+}
+
+func (e Endpoints) Validate() error {
+	if e.envErr != nil {
+		return e.envErr
+	}
+	u, err := url.Parse(e.DiscoveryURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid discovery URL %q", e.DiscoveryURL)
+	}
+	if e.DevicePort < 1 || e.DevicePort > 65535 {
+		return fmt.Errorf("invalid device port %d", e.DevicePort)
+	}
+	return nil
+}
+
+// DeviceURL returns the command endpoint of the device at ip. An address
+// that already carries a port ("192.168.1.50:8080") is used as is.
+func (e Endpoints) DeviceURL(ip string) string {
+	if _, _, err := net.SplitHostPort(ip); err == nil {
+		return fmt.Sprintf("http://%s/post", ip)
+	}
+	return fmt.Sprintf("http://%s/post", net.JoinHostPort(ip, strconv.Itoa(e.DevicePort)))
+}
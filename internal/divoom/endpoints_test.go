@@ -0,0 +1,76 @@
+package divoom
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func parseEndpoints(t *testing.T, args ...string) (Endpoints, error) {
+	t.Helper()
+	e := EndpointsFromEnv()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	e.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return e, err
+	}
+	return e, e.Validate()
+}
+
+func TestEndpointsPrecedence(t *testing.T) {
+	t.Setenv(EnvDiscoveryURL, "")
+	t.Setenv(EnvDevicePort, "")
+	e, err := parseEndpoints(t)
+	if err != nil || e.DiscoveryURL != DefaultDiscoveryURL || e.DevicePort != DefaultDevicePort {
+		t.Errorf("defaults: %+v, %v", e, err)
+	}
+
+	t.Setenv(EnvDiscoveryURL, "http://127.0.0.1:8080/devices")
+	t.Setenv(EnvDevicePort, "8081")
+	e, err = parseEndpoints(t)
+	if err != nil || e.DiscoveryURL != "http://127.0.0.1:8080/devices" || e.DevicePort != 8081 {
+		t.Errorf("environment: %+v, %v", e, err)
+	}
+
+	e, err = parseEndpoints(t, "--discovery-url", "https://example.com/lan", "--device-port", "9000")
+	if err != nil || e.DiscoveryURL != "https://example.com/lan" || e.DevicePort != 9000 {
+		t.Errorf("flags over environment: %+v, %v", e, err)
+	}
+}
+
+func TestEndpointsInvalidPort(t *testing.T) {
+	t.Setenv(EnvDiscoveryURL, "")
+	for _, port := range []string{"http", "80x", "0", "70000"} {
+		t.Setenv(EnvDevicePort, port)
+		if _, err := parseEndpoints(t); err == nil {
+			t.Errorf("%s=%s accepted", EnvDevicePort, port)
+		}
+	}
+
+	// A flag replaces the invalid value
+	t.Setenv(EnvDevicePort, "http")
+	if e, err := parseEndpoints(t, "--device-port", "8080"); err != nil || e.DevicePort != 8080 {
+		t.Errorf("--device-port over an invalid %s: %+v, %v", EnvDevicePort, e, err)
+	}
+	if _, err := parseEndpoints(t, "--device-port", "eighty"); err == nil {
+		t.Errorf("--device-port eighty accepted")
+	}
+	if _, err := parseEndpoints(t, "--discovery-url", "ftp://example.com"); err == nil {
+		t.Errorf("ftp discovery URL accepted")
+	}
+}
+
+func TestDeviceURL(t *testing.T) {
+	e := Endpoints{DiscoveryURL: DefaultDiscoveryURL, DevicePort: 8080}
+	tests := map[string]string{
+		"192.168.1.50":      "http://192.168.1.50:8080/post",
+		"192.168.1.50:9000": "http://192.168.1.50:9000/post",
+		"fe80::1":           "http://[fe80::1]:8080/post",
+	}
+	for ip, want := range tests {
+		if got := e.DeviceURL(ip); got != want {
+			t.Errorf("DeviceURL(%q) = %s, want %s", ip, got, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package divoom
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HardwareTimeGate is the Hardware code of the TimeGate, which has five LCDs.
+const HardwareTimeGate = 400
+
+// Capability is a group of commands a model accepts.
+type Capability int
+
+const (
+	CapPCMonitor Capability = 1 << iota // Device/UpdatePCParaInfo
+	CapText                             // Draw/SendHttpText
+	CapGif                              // Draw/SendHttpGif
+	CapChannel                          // Channel/* settings
+)
+
+// Model is the profile of a device model.
+type Model struct {
+	Name     string
+	Hardware int      // code reported by discovery, 0 where not confirmed
+	Match    []string // DeviceName prefixes, compared without case, spaces or dashes
+	Size     int      // pixels per side of Draw frames, 0 if not a pixel display
+	LCDs     int
+	Caps     Capability
+}
+
+// Models lists the known models. Only the TimeGate Hardware code has been
+// confirmed; the others are recognised by the DeviceName set at the factory.
+var Models = []Model{
+	{Name: "TimeGate", Hardware: HardwareTimeGate, Match: []string{"timegate"}, Size: 128, LCDs: 5,
+		Caps: CapPCMonitor | CapChannel},
+	{Name: "Pixoo 64", Match: []string{"pixoo64"}, Size: 64, LCDs: 1,
+		Caps: CapText | CapGif | CapChannel},
+	{Name: "Pixoo Max", Match: []string{"pixoomax"}, Size: 32, LCDs: 1,
+		Caps: CapText | CapGif | CapChannel},
+	{Name: "Pixoo 16", Match: []string{"pixoo16"}, Size: 16, LCDs: 1,
+		Caps: CapText | CapGif | CapChannel},
+	{Name: "Times Frame", Match: []string{"timesframe"}, LCDs: 1,
+		Caps: CapChannel},
+}
+
+// LookupModel finds the model of a discovered device, by Hardware code
+// first and then by name.
+func LookupModel(hardware int, deviceName string) (Model, bool) {
+	if hardware != 0 {
+		for _, model := range Models {
+			if model.Hardware == hardware {
+				return model, true
+			}
+		}
+	}
+	name := strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(deviceName))
+	for _, model := range Models {
+		for _, prefix := range model.Match {
+			if strings.HasPrefix(name, prefix) {
+				return model, true
+			}
+		}
+	}
+	return Model{}, false
+}
+
+// ModelName returns the model name of a discovered device, or
+// "Unknown (code)" if the model is not in Models.
+func ModelName(hardware int, deviceName string) string {
+	if model, ok := LookupModel(hardware, deviceName); ok {
+		return model.Name
+	}
+	return fmt.Sprintf("Unknown (%d)", hardware)
+}
+
+// Supports reports whether the model accepts the commands of c.
+func (m Model) Supports(c Capability) bool {
+	return m.Caps&c == c
+}
+
+// CheckLCD returns an error if the model has no LCD with the 0-based id.
+func (m Model) CheckLCD(lcdId int) error {
+	if lcdId < 0 || lcdId >= m.LCDs {
+		if m.LCDs == 1 {
+			return fmt.Errorf("%s has a single display, LCD ID must be 0", m.Name)
+		}
+		return fmt.Errorf("%s has %d LCDs, LCD ID must be between 0 and %d", m.Name, m.LCDs, m.LCDs-1)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package divoom
+
+import "testing"
+
+func TestLookupModel(t *testing.T) {
+	tests := []struct {
+		hardware int
+		name     string
+		want     string
+	}{
+		{HardwareTimeGate, "Living room", "TimeGate"},
+		{0, "Pixoo64", "Pixoo 64"},
+		{123, "Pixoo-64 Office", "Pixoo 64"},
+		{0, "pixoo max", "Pixoo Max"},
+		{0, "Times Frame", "Times Frame"},
+	}
+	for _, tt := range tests {
+		model, ok := LookupModel(tt.hardware, tt.name)
+		if !ok || model.Name != tt.want {
+			t.Errorf("LookupModel(%d, %q) = %q, %v, want %q", tt.hardware, tt.name, model.Name, ok, tt.want)
+		}
+	}
+	if _, ok := LookupModel(0, "Desk"); ok {
+		t.Errorf("LookupModel matched an unknown device")
+	}
+	if name := ModelName(7, "Desk"); name != "Unknown (7)" {
+		t.Errorf("ModelName = %q", name)
+	}
+}
+
+func TestModelCheckLCD(t *testing.T) {
+	timeGate, _ := LookupModel(HardwareTimeGate, "")
+	if err := timeGate.CheckLCD(4); err != nil {
+		t.Errorf("TimeGate CheckLCD(4): %v", err)
+	}
+	if err := timeGate.CheckLCD(5); err == nil {
+		t.Errorf("TimeGate accepted LCD 5")
+	}
+	pixoo, _ := LookupModel(0, "Pixoo64")
+	if err := pixoo.CheckLCD(1); err == nil {
+		t.Errorf("Pixoo 64 accepted LCD 1")
+	}
+	if !pixoo.Supports(CapText|CapGif) || pixoo.Supports(CapPCMonitor) || timeGate.Supports(CapGif) {
+		t.Errorf("unexpected capabilities")
+	}
+}
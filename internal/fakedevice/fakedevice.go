@@ -0,0 +1,372 @@
+// Package fakedevice implements a stand-in for a Divoom device on the LAN.
+//
+// It answers the device's /post command endpoint and the cloud discovery
+// endpoint (/Device/ReturnSameLANDevice), records every command it receives
+// and can be told to respond slowly, with an HTTP error or with a non-zero
+// error_code. Use NewServer in tests and Handler to serve it elsewhere.
+package fakedevice
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// Command is one request received on /post.
+type Command struct {
+	Name string                     `json:"Command"`
+	Body map[string]json.RawMessage `json:"Body"`
+	Raw  []byte                     `json:"-"`
+	Time time.Time                  `json:"Time"`
+}
+
+// Decode unmarshals the full request body into v.
+func (c Command) Decode(v interface{}) error {
+	return json.Unmarshal(c.Raw, v)
+}
+
+// DeviceInfo is the entry returned by the fake discovery endpoint.
+type DeviceInfo struct {
+	DeviceName      string `json:"DeviceName"`
+	DeviceId        int    `json:"DeviceId"`
+	DevicePrivateIP string `json:"DevicePrivateIP"`
+	DeviceMac       string `json:"DeviceMac"`
+	Hardware        int    `json:"Hardware"`
+}
+
+// State is what the fake device currently shows, as changed by commands.
+type State struct {
+	Brightness  int              `json:"Brightness"`
+	LightSwitch int              `json:"LightSwitch"`
+	SelectIndex int              `json:"SelectIndex"`
+	CurClockId  int              `json:"CurClockId"`
+	DispData    map[int][]string `json:"DispData"`
+	Text        map[int]string   `json:"Text"`
+	PicId       int              `json:"PicId"`
+}
+
+// Device is the fake device. The zero value is not usable; call New.
+type Device struct {
+	mu        sync.Mutex
+	info      DeviceInfo
+	devices   []DeviceInfo
+	commands  []Command
+	state     State
+	latency   time.Duration
+	status    int
+	errorCode int
+	onCommand func(Command)
+}
+
+// New returns a device that reports itself as info in discovery.
+func New(info DeviceInfo) *Device {
+	return &Device{
+		info: info,
+		state: State{
+			Brightness:  100,
+			LightSwitch: 1,
+			CurClockId:  625,
+			DispData:    make(map[int][]string),
+			Text:        make(map[int]string),
+		},
+	}
+}
+
+// SetLatency delays every response by d.
+func (d *Device) SetLatency(latency time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.latency = latency
+}
+
+// SetHTTPStatus makes /post answer with status; 0 restores 200 OK.
+func (d *Device) SetHTTPStatus(status int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status = status
+}
+
+// SetErrorCode makes /post answer 200 OK with this error_code.
+func (d *Device) SetErrorCode(code int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.errorCode = code
+}
+
+// OnCommand registers fn to be called for every command received.
+func (d *Device) OnCommand(fn func(Command)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onCommand = fn
+}
+
+// SetDevices replaces the discovery list. By default only the device itself
+// is listed.
+func (d *Device) SetDevices(devices []DeviceInfo) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.devices = devices
+}
+
+// Info returns the device's own discovery entry.
+func (d *Device) Info() DeviceInfo {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.info
+}
+
+// Commands returns all commands received so far.
+func (d *Device) Commands() []Command {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Command(nil), d.commands...)
+}
+
+// CommandsNamed returns the received commands with the given name.
+func (d *Device) CommandsNamed(name string) []Command {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var matched []Command
+	for _, c := range d.commands {
+		if c.Name == name {
+			matched = append(matched, c)
+		}
+	}
+	return matched
+}
+
+// State returns a copy of the current device state.
+func (d *Device) State() State {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	state := d.state
+	state.DispData = make(map[int][]string, len(d.state.DispData))
+	for lcd, data := range d.state.DispData {
+		state.DispData[lcd] = append([]string(nil), data...)
+	}
+	state.Text = make(map[int]string, len(d.state.Text))
+	for id, text := range d.state.Text {
+		state.Text[id] = text
+	}
+	return state
+}
+
+// Reset forgets recorded commands and clears injected failures.
+func (d *Device) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.commands = nil
+	d.latency = 0
+	d.status = 0
+	d.errorCode = 0
+}
+
+// Handler serves /post and /Device/ReturnSameLANDevice.
+func (d *Device) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/post", d.handlePost)
+	mux.HandleFunc("/Device/ReturnSameLANDevice", d.handleDiscovery)
+	return mux
+}
+
+func (d *Device) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	devices := d.devices
+	if devices == nil {
+		devices = []DeviceInfo{d.info}
+	}
+	d.mu.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"ReturnCode":    0,
+		"ReturnMessage": "",
+		"TotalData":     len(devices),
+		"DeviceList":    devices,
+	})
+}
+
+func (d *Device) handlePost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d.mu.Lock()
+	latency, status, errorCode := d.latency, d.status, d.errorCode
+	d.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &body); err != nil {
+		// The real device answers illegal JSON with a string error_code
+		writeJSON(w, map[string]interface{}{"error_code": "Request data illegal json"})
+		return
+	}
+	var name string
+	json.Unmarshal(body["Command"], &name)
+
+	command := Command{Name: name, Body: body, Raw: raw, Time: time.Now()}
+	d.mu.Lock()
+	d.commands = append(d.commands, command)
+	onCommand := d.onCommand
+	d.mu.Unlock()
+	if onCommand != nil {
+		onCommand(command)
+	}
+
+	if status != 0 && status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if errorCode != 0 {
+		writeJSON(w, map[string]interface{}{"error_code": errorCode})
+		return
+	}
+
+	d.mu.Lock()
+	response := d.apply(name, raw)
+	d.mu.Unlock()
+
+	response["error_code"] = 0
+	writeJSON(w, response)
+}
+
+// apply updates the device state for a command and returns the extra
+// response fields. Unknown commands are accepted and recorded.
+func (d *Device) apply(name string, raw []byte) map[string]interface{} {
+	response := make(map[string]interface{})
+	switch name {
+	case "Device/UpdatePCParaInfo":
+		var req struct {
+			ScreenList []struct {
+				LcdId    int      `json:"LcdId"`
+				DispData []string `json:"DispData"`
+			} `json:"ScreenList"`
+		}
+		json.Unmarshal(raw, &req)
+		for _, screen := range req.ScreenList {
+			d.state.DispData[screen.LcdId] = screen.DispData
+		}
+	case "Draw/SendHttpText":
+		var req struct {
+			TextId     int    `json:"TextId"`
+			TextString string `json:"TextString"`
+		}
+		json.Unmarshal(raw, &req)
+		d.state.Text[req.TextId] = req.TextString
+	case "Draw/ClearHttpText":
+		d.state.Text = make(map[int]string)
+	case "Draw/ResetHttpGifId":
+		d.state.PicId = 0
+	case "Draw/GetHttpGifId":
+		response["PicId"] = d.state.PicId + 1
+	case "Draw/SendHttpGif":
+		var req struct {
+			PicID int `json:"PicID"`
+		}
+		json.Unmarshal(raw, &req)
+		d.state.PicId = req.PicID
+	case "Channel/SetBrightness":
+		var req struct {
+			Brightness int `json:"Brightness"`
+		}
+		json.Unmarshal(raw, &req)
+		d.state.Brightness = req.Brightness
+	case "Channel/OnOffScreen":
+		var req struct {
+			OnOff int `json:"OnOff"`
+		}
+		json.Unmarshal(raw, &req)
+		d.state.LightSwitch = req.OnOff
+	case "Channel/SetIndex":
+		var req struct {
+			SelectIndex int `json:"SelectIndex"`
+		}
+		json.Unmarshal(raw, &req)
+		d.state.SelectIndex = req.SelectIndex
+	case "Channel/GetIndex":
+		response["SelectIndex"] = d.state.SelectIndex
+	case "Channel/SetClockSelectId":
+		var req struct {
+			ClockId int `json:"ClockId"`
+		}
+		json.Unmarshal(raw, &req)
+		d.state.CurClockId = req.ClockId
+		d.state.SelectIndex = 0
+	case "Channel/GetClockInfo":
+		response["ClockId"] = d.state.CurClockId
+		response["Brightness"] = d.state.Brightness
+	case "Channel/GetAllConf":
+		response["Brightness"] = d.state.Brightness
+		response["LightSwitch"] = d.state.LightSwitch
+		response["CurClockId"] = d.state.CurClockId
+		response["SelectIndex"] = d.state.SelectIndex
+		response["RotationFlag"] = 0
+		response["ClockTime"] = 60
+		response["GalleryTime"] = 60
+		response["SingleGalleyTime"] = 5
+		response["PowerOnChannelId"] = 0
+		response["Time24Flag"] = 1
+		response["TemperatureMode"] = 0
+		response["MirrorFlag"] = 0
+	}
+	return response
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Server is a fake device listening on a local httptest server.
+type Server struct {
+	*Device
+	*httptest.Server
+}
+
+// NewServer starts a fake device on 127.0.0.1 with a random port. Its
+// discovery entry points at the server's address.
+func NewServer() *Server {
+	device := New(DeviceInfo{
+		DeviceName: "Fake TimeGate",
+		DeviceId:   300000001,
+		DeviceMac:  "a1b2c3d4e5f6",
+		Hardware:   400,
+	})
+	server := httptest.NewServer(device.Handler())
+	device.info.DevicePrivateIP = server.Listener.Addr().(*net.TCPAddr).IP.String()
+	return &Server{Device: device, Server: server}
+}
+
+// Host returns the server's IP address, as listed in discovery.
+func (s *Server) Host() string {
+	return s.Listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port the server listens on.
+func (s *Server) Port() int {
+	return s.Listener.Addr().(*net.TCPAddr).Port
+}
+
+// DiscoveryURL returns the URL of the fake discovery endpoint.
+func (s *Server) DiscoveryURL() string {
+	return fmt.Sprintf("%s/Device/ReturnSameLANDevice", s.URL)
+}
@@ -0,0 +1,106 @@
+package fakedevice
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func post(t *testing.T, s *Server, body string) (int, map[string]interface{}) {
+	t.Helper()
+	resp, err := s.Client().Post(s.URL+"/post", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var out map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func TestRecordsPCMonitorUpdate(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	status, out := post(t, s, `{"Command":"Device/UpdatePCParaInfo","ScreenList":[{"LcdId":2,"DispData":["1%","2%","3°C","4°C","5%","6°C"]}]}`)
+	if status != http.StatusOK || out["error_code"] != float64(0) {
+		t.Fatalf("status %d, body %v", status, out)
+	}
+
+	commands := s.CommandsNamed("Device/UpdatePCParaInfo")
+	if len(commands) != 1 {
+		t.Fatalf("recorded %d commands, want 1", len(commands))
+	}
+	if got := s.State().DispData[2]; len(got) != 6 || got[0] != "1%" || got[5] != "6°C" {
+		t.Errorf("DispData[2] = %v", got)
+	}
+}
+
+func TestChannelCommandsUpdateConf(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	post(t, s, `{"Command":"Channel/SetBrightness","Brightness":30}`)
+	post(t, s, `{"Command":"Channel/OnOffScreen","OnOff":0}`)
+	post(t, s, `{"Command":"Channel/SetClockSelectId","ClockId":42}`)
+
+	_, conf := post(t, s, `{"Command":"Channel/GetAllConf"}`)
+	if conf["Brightness"] != float64(30) || conf["LightSwitch"] != float64(0) || conf["CurClockId"] != float64(42) {
+		t.Errorf("GetAllConf = %v", conf)
+	}
+}
+
+func TestInjectedFailures(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	s.SetErrorCode(1)
+	if _, out := post(t, s, `{"Command":"Channel/SetBrightness","Brightness":10}`); out["error_code"] != float64(1) {
+		t.Errorf("error_code = %v, want 1", out["error_code"])
+	}
+	if s.State().Brightness != 100 {
+		t.Errorf("failed command changed brightness to %d", s.State().Brightness)
+	}
+
+	s.Reset()
+	s.SetHTTPStatus(http.StatusServiceUnavailable)
+	if status, _ := post(t, s, `{"Command":"Channel/GetAllConf"}`); status != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", status)
+	}
+
+	s.Reset()
+	s.SetLatency(50 * time.Millisecond)
+	start := time.Now()
+	post(t, s, `{"Command":"Channel/GetAllConf"}`)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("response took %s, want at least 50ms", elapsed)
+	}
+}
+
+func TestDiscoveryListsDevice(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.DiscoveryURL())
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var list struct {
+		TotalData  int
+		DeviceList []DeviceInfo
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if list.TotalData != 1 || len(list.DeviceList) != 1 {
+		t.Fatalf("discovery returned %+v", list)
+	}
+	if got := list.DeviceList[0]; got.DevicePrivateIP != s.Host() || got.Hardware != 400 {
+		t.Errorf("device = %+v", got)
+	}
+}
@@ -0,0 +1,163 @@
+package pixel
+
+import (
+	"image"
+	"image/color"
+	"unicode"
+)
+
+// The built-in font has 3x5 pixel glyphs drawn with one pixel of spacing.
+const (
+	GlyphWidth   = 3
+	GlyphHeight  = 5
+	glyphAdvance = GlyphWidth + 1
+)
+
+// glyphs holds one row per byte, the leftmost pixel in bit 2. Lower case
+// letters are drawn with the upper case glyphs.
+var glyphs = map[rune][GlyphHeight]uint8{
+	'0': {0b111, 0b101, 0b101, 0b101, 0b111},
+	'1': {0b010, 0b110, 0b010, 0b010, 0b111},
+	'2': {0b111, 0b001, 0b111, 0b100, 0b111},
+	'3': {0b111, 0b001, 0b111, 0b001, 0b111},
+	'4': {0b101, 0b101, 0b111, 0b001, 0b001},
+	'5': {0b111, 0b100, 0b111, 0b001, 0b111},
+	'6': {0b111, 0b100, 0b111, 0b101, 0b111},
+	'7': {0b111, 0b001, 0b001, 0b001, 0b001},
+	'8': {0b111, 0b101, 0b111, 0b101, 0b111},
+	'9': {0b111, 0b101, 0b111, 0b001, 0b111},
+	'A': {0b010, 0b101, 0b111, 0b101, 0b101},
+	'B': {0b110, 0b101, 0b110, 0b101, 0b110},
+	'C': {0b011, 0b100, 0b100, 0b100, 0b011},
+	'D': {0b110, 0b101, 0b101, 0b101, 0b110},
+	'E': {0b111, 0b100, 0b110, 0b100, 0b111},
+	'F': {0b111, 0b100, 0b110, 0b100, 0b100},
+	'G': {0b011, 0b100, 0b101, 0b101, 0b011},
+	'H': {0b101, 0b101, 0b111, 0b101, 0b101},
+	'I': {0b111, 0b010, 0b010, 0b010, 0b111},
+	'J': {0b001, 0b001, 0b001, 0b101, 0b010},
+	'K': {0b101, 0b101, 0b110, 0b101, 0b101},
+	'L': {0b100, 0b100, 0b100, 0b100, 0b111},
+	'M': {0b101, 0b111, 0b111, 0b101, 0b101},
+	'N': {0b110, 0b101, 0b101, 0b101, 0b101},
+	'O': {0b010, 0b101, 0b101, 0b101, 0b010},
+	'P': {0b110, 0b101, 0b110, 0b100, 0b100},
+	'Q': {0b010, 0b101, 0b101, 0b110, 0b011},
+	'R': {0b110, 0b101, 0b110, 0b101, 0b101},
+	'S': {0b011, 0b100, 0b010, 0b001, 0b110},
+	'T': {0b111, 0b010, 0b010, 0b010, 0b010},
+	'U': {0b101, 0b101, 0b101, 0b101, 0b111},
+	'V': {0b101, 0b101, 0b101, 0b101, 0b010},
+	'W': {0b101, 0b101, 0b111, 0b111, 0b101},
+	'X': {0b101, 0b101, 0b010, 0b101, 0b101},
+	'Y': {0b101, 0b101, 0b010, 0b010, 0b010},
+	'Z': {0b111, 0b001, 0b010, 0b100, 0b111},
+	' ': {0b000, 0b000, 0b000, 0b000, 0b000},
+	'%': {0b101, 0b001, 0b010, 0b100, 0b101},
+	'°': {0b010, 0b101, 0b010, 0b000, 0b000},
+	':': {0b000, 0b010, 0b000, 0b010, 0b000},
+	'.': {0b000, 0b000, 0b000, 0b000, 0b010},
+	',': {0b000, 0b000, 0b000, 0b010, 0b100},
+	'-': {0b000, 0b000, 0b111, 0b000, 0b000},
+	'+': {0b000, 0b010, 0b111, 0b010, 0b000},
+	'/': {0b001, 0b001, 0b010, 0b100, 0b100},
+	'(': {0b010, 0b100, 0b100, 0b100, 0b010},
+	')': {0b010, 0b001, 0b001, 0b001, 0b010},
+	'!': {0b010, 0b010, 0b010, 0b000, 0b010},
+	'?': {0b111, 0b001, 0b010, 0b000, 0b010},
+}
+
+// TextWidth returns the width in pixels of s drawn with DrawText.
+func TextWidth(s string) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return n*glyphAdvance - 1
+}
+
+// DrawText draws s with its top left corner at x, y and returns the x
+// position after the text. Characters without a glyph are drawn as '?'.
+func DrawText(img *image.RGBA, x, y int, s string, c color.RGBA) int {
+	for _, r := range s {
+		glyph, ok := glyphs[unicode.ToUpper(r)]
+		if !ok {
+			glyph = glyphs['?']
+		}
+		for row, bits := range glyph {
+			for col := 0; col < GlyphWidth; col++ {
+				if bits&(1<<(GlyphWidth-1-col)) != 0 {
+					img.SetRGBA(x+col, y+row, c)
+				}
+			}
+		}
+		x += glyphAdvance
+	}
+	return x
+}
+
+// icons are 5x5 pictures, '#' marking a lit pixel.
+var icons = map[string][5]string{
+	"cpu": {
+		".#.#.",
+		"#####",
+		".#.#.",
+		"#####",
+		".#.#.",
+	},
+	"gpu": {
+		"#####",
+		"#.#.#",
+		"##.##",
+		"#.#.#",
+		"#####",
+	},
+	"memory": {
+		".....",
+		"#####",
+		"#####",
+		"#.#.#",
+		".....",
+	},
+	"disk": {
+		".###.",
+		"#...#",
+		"#.#.#",
+		"#...#",
+		".###.",
+	},
+	"temp": {
+		"..#..",
+		"..#..",
+		"..#..",
+		".###.",
+		".###.",
+	},
+	"alert": {
+		"..#..",
+		"..#..",
+		".###.",
+		".#.#.",
+		"#####",
+	},
+}
+
+// IconSize is the width and height of the built-in icons.
+const IconSize = 5
+
+// HasIcon reports whether name is a built-in icon.
+func HasIcon(name string) bool {
+	_, ok := icons[name]
+	return ok
+}
+
+// DrawIcon draws the named icon with its top left corner at x, y.
+func DrawIcon(img *image.RGBA, x, y int, name string, c color.RGBA) {
+	for row, line := range icons[name] {
+		for col, p := range line {
+			if p == '#' {
+				img.SetRGBA(x+col, y+row, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package pixel draws small RGB frames for Divoom pixel displays and encodes
+// them for the Draw/SendHttpGif command.
+package pixel
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// Frame sizes accepted by Draw/SendHttpGif.
+var Sizes = []int{16, 32, 64}
+
+// ValidSize reports whether size is a frame size the devices accept.
+func ValidSize(size int) bool {
+	for _, s := range Sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// NewFrame returns a black size x size frame.
+func NewFrame(size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	Fill(img, img.Bounds(), color.RGBA{A: 255})
+	return img
+}
+
+// ParseColor parses "#RRGGBB".
+func ParseColor(s string) (color.RGBA, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, want #RRGGBB", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, want #RRGGBB", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
+
+// Dim returns c at the given brightness (0-1).
+func Dim(c color.RGBA, factor float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(c.R) * factor),
+		G: uint8(float64(c.G) * factor),
+		B: uint8(float64(c.B) * factor),
+		A: c.A,
+	}
+}
+
+// Fill paints r with c.
+func Fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// level scales value against max to a height of 0..h pixels.
+func level(value, max float64, h int) int {
+	if max <= 0 || value <= 0 {
+		return 0
+	}
+	n := int(math.Round(value / max * float64(h)))
+	if n > h {
+		n = h
+	}
+	if n == 0 {
+		n = 1 // show that there is something
+	}
+	return n
+}
+
+// Sparkline draws values as a filled area chart in r, oldest on the left and
+// one column per value. Only the newest r.Dx() values are drawn; max is the
+// value drawn at full height.
+func Sparkline(img *image.RGBA, r image.Rectangle, values []float64, max float64, c color.RGBA) {
+	if len(values) > r.Dx() {
+		values = values[len(values)-r.Dx():]
+	}
+	fill := Dim(c, 0.35)
+	x := r.Max.X - len(values)
+	for _, v := range values {
+		n := level(v, max, r.Dy())
+		if n > 0 {
+			Fill(img, image.Rect(x, r.Max.Y-n+1, x+1, r.Max.Y), fill)
+			img.SetRGBA(x, r.Max.Y-n, c)
+		}
+		x++
+	}
+}
+
+// Bar draws value as a horizontal bar across r on a dimmed track.
+func Bar(img *image.RGBA, r image.Rectangle, value, max float64, c color.RGBA) {
+	Fill(img, r, Dim(c, 0.15))
+	n := level(value, max, r.Dx())
+	Fill(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+n, r.Max.Y), c)
+}
+
+// PicData encodes img as the base64 RGB bytes, row by row, that
+// Draw/SendHttpGif expects in PicData.
+func PicData(img *image.RGBA) string {
+	b := img.Bounds()
+	raw := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			raw = append(raw, c.R, c.G, c.B)
+		}
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
@@ -0,0 +1,90 @@
+package pixel
+
+import (
+	"encoding/base64"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPicDataIsRowMajorRGB(t *testing.T) {
+	img := NewFrame(16)
+	img.SetRGBA(1, 0, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+	img.SetRGBA(0, 1, color.RGBA{R: 40, G: 50, B: 60, A: 255})
+
+	raw, err := base64.StdEncoding.DecodeString(PicData(img))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(raw) != 16*16*3 {
+		t.Fatalf("got %d bytes, want %d", len(raw), 16*16*3)
+	}
+	if got := raw[3:6]; got[0] != 10 || got[1] != 20 || got[2] != 30 {
+		t.Errorf("pixel (1,0) = %v", got)
+	}
+	if got := raw[16*3 : 16*3+3]; got[0] != 40 || got[1] != 50 || got[2] != 60 {
+		t.Errorf("pixel (0,1) = %v", got)
+	}
+}
+
+func TestSparklineScalesToHeight(t *testing.T) {
+	img := NewFrame(16)
+	c := color.RGBA{G: 255, A: 255}
+	Sparkline(img, image.Rect(0, 0, 16, 8), []float64{100, 50, 0}, 100, c)
+
+	// Newest values are right-aligned: x=13 is 100%, x=14 is 50%, x=15 is 0
+	if img.RGBAAt(13, 0) != c {
+		t.Errorf("full value does not reach the top")
+	}
+	if img.RGBAAt(14, 4) != c || img.RGBAAt(14, 3) == c {
+		t.Errorf("half value is not drawn at half height")
+	}
+	if img.RGBAAt(15, 7) != (color.RGBA{A: 255}) {
+		t.Errorf("zero value was drawn")
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	c, err := ParseColor("#FF8000")
+	if err != nil || c != (color.RGBA{R: 255, G: 128, A: 255}) {
+		t.Errorf("ParseColor = %v, %v", c, err)
+	}
+	if _, err := ParseColor("orange"); err == nil {
+		t.Errorf("ParseColor accepted a name")
+	}
+}
+
+type stubData map[string]float64
+
+func (d stubData) Text(template string) string     { return template }
+func (d stubData) Value(metric string) float64     { return d[metric] }
+func (d stubData) History(metric string) []float64 { return []float64{d[metric]} }
+
+func TestScreenRendersWidgets(t *testing.T) {
+	screen := Screen{
+		Size: 16,
+		Widgets: []Widget{
+			{Type: "text", X: 0, Y: 0, W: 16, Text: "1", Align: "right"},
+			{Type: "bar", X: 0, Y: 10, W: 16, H: 2, Metric: "Load", Color: "#FF0000"},
+		},
+	}
+	if err := screen.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	img := screen.Render(stubData{"Load": 50})
+
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	// '1' is 3 pixels wide; right aligned its bottom row spans x=13..15
+	if img.RGBAAt(13, 4) != white || img.RGBAAt(15, 4) != white || img.RGBAAt(12, 4) == white {
+		t.Errorf("text is not right aligned")
+	}
+	red := color.RGBA{R: 255, A: 255}
+	if img.RGBAAt(7, 10) != red || img.RGBAAt(8, 10) == red {
+		t.Errorf("bar does not stop at half width")
+	}
+
+	screen.Widgets = append(screen.Widgets, Widget{Type: "icon", Icon: "fan"})
+	if err := screen.Validate(); err == nil {
+		t.Errorf("Validate accepted an unknown icon")
+	}
+}
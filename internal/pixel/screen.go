@@ -0,0 +1,144 @@
+package pixel
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Screen is a declarative description of a frame: a size, a background and
+// widgets drawn in order at fixed positions.
+type Screen struct {
+	Size       int      `json:"Size"`       // 16, 32 or 64
+	Background string   `json:"Background"` // #RRGGBB, default black
+	Widgets    []Widget `json:"Widgets"`
+}
+
+// Widget is one element of a Screen.
+//
+//	text       Text at X,Y; with W set it is aligned within W by Align
+//	icon       built-in Icon (cpu, gpu, memory, disk, temp, alert) at X,Y
+//	bar        horizontal bar of Metric in the W x H box
+//	sparkline  history of Metric in the W x H box
+//	rect       filled W x H box, e.g. as a separator
+type Widget struct {
+	Type   string  `json:"Type"`
+	X      int     `json:"X"`
+	Y      int     `json:"Y"`
+	W      int     `json:"W"`
+	H      int     `json:"H"`
+	Text   string  `json:"Text"`
+	Align  string  `json:"Align"` // left (default), center or right
+	Icon   string  `json:"Icon"`
+	Metric string  `json:"Metric"`
+	Max    float64 `json:"Max"` // value drawn at full size, default 100
+	Color  string  `json:"Color"`
+
+	color color.RGBA
+}
+
+// ScreenData supplies what the widgets show. Text expands the templates of
+// text widgets.
+type ScreenData interface {
+	Text(template string) string
+	Value(metric string) float64
+	History(metric string) []float64
+}
+
+// Validate checks the screen and fills in defaults.
+func (s *Screen) Validate() error {
+	if s.Size == 0 {
+		s.Size = 64
+	}
+	if !ValidSize(s.Size) {
+		return fmt.Errorf("Size must be one of %v", Sizes)
+	}
+	if s.Background != "" {
+		if _, err := ParseColor(s.Background); err != nil {
+			return err
+		}
+	}
+	for i := range s.Widgets {
+		if err := s.Widgets[i].validate(s.Size); err != nil {
+			return fmt.Errorf("widget %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (w *Widget) validate(size int) error {
+	if w.X < 0 || w.Y < 0 || w.X >= size || w.Y >= size {
+		return fmt.Errorf("position %d,%d is outside the %dx%d frame", w.X, w.Y, size, size)
+	}
+	if w.Color == "" {
+		w.Color = "#FFFFFF"
+	}
+	c, err := ParseColor(w.Color)
+	if err != nil {
+		return err
+	}
+	w.color = c
+	if w.Max == 0 {
+		w.Max = 100
+	}
+
+	switch w.Type {
+	case "text":
+		switch w.Align {
+		case "", "left", "center", "right":
+		default:
+			return fmt.Errorf("unknown alignment %q", w.Align)
+		}
+	case "icon":
+		if !HasIcon(w.Icon) {
+			return fmt.Errorf("unknown icon %q", w.Icon)
+		}
+	case "bar", "sparkline":
+		if w.Metric == "" {
+			return fmt.Errorf("%s needs a Metric", w.Type)
+		}
+		fallthrough
+	case "rect":
+		if w.W <= 0 || w.H <= 0 {
+			return fmt.Errorf("%s needs a positive W and H", w.Type)
+		}
+	default:
+		return fmt.Errorf("unknown widget type %q", w.Type)
+	}
+	return nil
+}
+
+// Render draws the screen. It must have been validated.
+func (s *Screen) Render(data ScreenData) *image.RGBA {
+	img := NewFrame(s.Size)
+	if s.Background != "" {
+		bg, _ := ParseColor(s.Background)
+		Fill(img, img.Bounds(), bg)
+	}
+	for _, w := range s.Widgets {
+		box := image.Rect(w.X, w.Y, w.X+w.W, w.Y+w.H)
+		switch w.Type {
+		case "text":
+			text := data.Text(w.Text)
+			x := w.X
+			if w.W > 0 {
+				switch w.Align {
+				case "center":
+					x += (w.W - TextWidth(text)) / 2
+				case "right":
+					x += w.W - TextWidth(text)
+				}
+			}
+			DrawText(img, x, w.Y, text, w.color)
+		case "icon":
+			DrawIcon(img, w.X, w.Y, w.Icon, w.color)
+		case "bar":
+			Bar(img, box, data.Value(w.Metric), w.Max, w.color)
+		case "sparkline":
+			Sparkline(img, box, data.History(w.Metric), w.Max, w.color)
+		case "rect":
+			Fill(img, box, w.color)
+		}
+	}
+	return img
+}
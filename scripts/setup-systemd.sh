@@ -64,6 +64,7 @@ echo -e "${GREEN}✓ Directory /var/lib/divoom created${NC}"
 # Copy binaries
 echo "Installing binaries to /usr/bin..."
 cp -f bin/divoom-daemon /usr/bin/
+cp -f bin/divoom-ctl /usr/bin/
 cp -f bin/divoom-monitor /usr/bin/
 cp -f bin/divoom-test /usr/bin/
 cp -f bin/divoom-auto /usr/bin/